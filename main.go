@@ -3,10 +3,18 @@ package main
 import (
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"time"
 
 	notecli "github.com/MonkeyBuisness/celli/notebook/cli"
+	"github.com/MonkeyBuisness/celli/notebook/config"
+	"github.com/MonkeyBuisness/celli/notebook/converter"
+	"github.com/MonkeyBuisness/celli/notebook/runner"
+	"github.com/MonkeyBuisness/celli/notebook/serializer"
+	"github.com/MonkeyBuisness/celli/notebook/template"
 	"github.com/MonkeyBuisness/celli/notebook/types"
+	"github.com/MonkeyBuisness/celli/notebook/watch"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 )
@@ -17,14 +25,82 @@ var (
 )
 
 func main() {
+	// keep multiline messages (e.g. source excerpts) readable.
+	logrus.SetFormatter(&logrus.TextFormatter{
+		DisableQuote: true,
+	})
+
+	// the configuration is loaded before the commands (see configure), flags override it.
+	cfg, cfgPath := config.Default(), ""
+	notecli.Configure(cfg)
+
 	var (
-		prettyBookFlag bool
+		prettyBookFlag     bool
+		fencedCodeFlag     bool
+		strictFlag         bool
+		reportFormat       string
+		diffFormat         string
+		gitFilterCommand   string
+		gitFilterBookTypes cli.StringSlice
+		frontMatterFlag    bool
+		runTimeout         time.Duration
+		runOnly            string
+		runRunners         cli.StringSlice
+		watchDebounce      time.Duration
+		outputOpts         notecli.OutputOptions
+		templatePaths      cli.StringSlice
+		batchOpts          = notecli.BatchOptions{
+			BookType: cfg.BookType,
+		}
 	)
+	if batchOpts.BookType == "" {
+		batchOpts.BookType = string(types.BookTypeJavaBook)
+	}
+
+	// configure loads the configuration of the working directory before the command runs,
+	// so a broken configuration file fails the command instead of the help.
+	// Flags that are not set explicitly take the configured values.
+	configure := func(c *cli.Context) error {
+		loaded, path, err := config.Load(".")
+		if err != nil {
+			return err
+		}
+		loaded.TrustPlugins = c.Bool("trust-plugins")
+		*cfg, cfgPath = *loaded, path
+
+		if configDefault(c, "pretty") {
+			prettyBookFlag = cfg.Pretty
+		}
+		if configDefault(c, "fenced-code") {
+			fencedCodeFlag = cfg.FencedCode
+		}
+		if configDefault(c, "strict") {
+			strictFlag = cfg.Strict
+		}
+		if configDefault(c, "front-matter") {
+			frontMatterFlag = cfg.FrontMatter
+		}
+		if configDefault(c, "format") {
+			reportFormat = cfg.ReportFormat
+		}
+		if configDefault(c, "book-type") && cfg.BookType != "" {
+			batchOpts.BookType = cfg.BookType
+		}
+
+		return nil
+	}
 
 	app := &cli.App{
 		Name:    appName,
 		Usage:   "work with cellementaty notebooks in the easiest way",
 		Version: appVersion,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "trust-plugins",
+				Usage:   "run the plugins declared by the project (" + config.FileName + ", .celli/plugins.json)",
+				EnvVars: []string{notecli.TrustPluginsEnvVar},
+			},
+		},
 		Commands: []*cli.Command{
 			{
 				Name:        "version",
@@ -39,76 +115,663 @@ func main() {
 				Aliases:  []string{"n", "create"},
 				Category: "template",
 				Description: fmt.Sprintf("Supported template types: %s",
-					strings.Join(types.SupportedBookTypes(), ",")),
+					strings.Join(supportedBookTypes(), ",")),
 				Usage:       "new <type of the notebook template to create>",
-				Subcommands: createNewSubcommands(),
+				Flags:       newTemplateFlags(&outputOpts, &templatePaths),
+				Subcommands: createNewSubcommands(&outputOpts, &templatePaths),
+				Before:      configure,
+				Action: func(c *cli.Context) error {
+					if cfg.BookType == "" {
+						return cli.ShowSubcommandHelp(c)
+					}
+
+					return createTemplate(cfg.BookType, outputOpts, templatePaths)
+				},
+			},
+			{
+				Name:        "config",
+				Category:    "config",
+				Description: "shows the effective configuration",
+				Usage:       "config show",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "show",
+						Usage:  "prints the effective configuration merged from the defaults, " + config.FileName + " and plugins",
+						Flags:  outputFlags(&outputOpts),
+						Before: configure,
+						Action: func(c *cli.Context) error {
+							return notecli.ShowConfig(cfg, cfgPath, outputOpts)
+						},
+					},
+				},
+			},
+			{
+				Name:     "validate",
+				Aliases:  []string{"lint"},
+				Category: "template",
+				Description: fmt.Sprintf("reports all the problems found in the template. Supported formats: %s",
+					strings.Join(notecli.SupportedReportFormats(), ",")),
+				Usage: "validate <path to the template file>",
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{
+						Name:        "format",
+						Aliases:     []string{"f"},
+						Value:       cfg.ReportFormat,
+						Usage:       "output format of the report",
+						Destination: &reportFormat,
+					},
+					&cli.BoolFlag{
+						Name:        "fenced-code",
+						Value:       cfg.FencedCode,
+						Usage:       "convert fenced code blocks with a language (```java) to the code cells",
+						Destination: &fencedCodeFlag,
+					},
+					&cli.BoolFlag{
+						Name:        "front-matter",
+						Value:       cfg.FrontMatter,
+						Usage:       "merge YAML front matter at the top of the template into the notebook metadata",
+						Destination: &frontMatterFlag,
+					},
+				}, outputFlags(&outputOpts)...),
+				Before: configure,
+				Action: func(c *cli.Context) error {
+					templatePath := c.Args().First()
+					// validation is always strict.
+					opts := serializerOptions(fencedCodeFlag, false, frontMatterFlag)
+
+					return notecli.ValidateTemplate(templatePath, reportFormat, outputOpts, opts...)
+				},
+			},
+			{
+				Name:     "lock",
+				Category: "template",
+				Description: "records SHA-256 hashes of all the remote URIs referenced from the template " +
+					"into the lockfile, later conversions verify them",
+				Usage:  "lock <path to the template file>",
+				Before: configure,
+				Action: func(c *cli.Context) error {
+					templatePath := c.Args().First()
+					return notecli.LockTemplate(templatePath)
+				},
+			},
+			{
+				Name:     "run",
+				Category: "notebook",
+				Description: "executes code cells of the notebook (or template) and prints the notebook " +
+					"with stdout, stderr and exit status of every cell stored as the cell outputs",
+				Usage: "run [--output destination.notebook] <path to the notebook or template file>",
+				Flags: append([]cli.Flag{
+					&cli.BoolFlag{
+						Name:        "pretty",
+						Aliases:     []string{"p"},
+						Value:       cfg.Pretty,
+						Usage:       "pretty JSON output for notebook document",
+						Destination: &prettyBookFlag,
+					},
+					&cli.DurationFlag{
+						Name:        "timeout",
+						Aliases:     []string{"t"},
+						Value:       runner.DefaultTimeout,
+						Usage:       "timeout of the cell execution, overridden by the `timeout` cell metadata",
+						Destination: &runTimeout,
+					},
+					&cli.StringFlag{
+						Name:        "only",
+						Usage:       "run only the selected code cells (1-based numbers of the code cells and ranges, e.g. 1,3-5)",
+						Destination: &runOnly,
+					},
+					&cli.StringSliceFlag{
+						Name:        "runner",
+						Aliases:     []string{"r"},
+						Usage:       "command that runs cells of the language (e.g. \"java=java --enable-preview {file}\")",
+						Destination: &runRunners,
+					},
+					&cli.BoolFlag{
+						Name:        "fenced-code",
+						Aliases:     []string{"f"},
+						Value:       cfg.FencedCode,
+						Usage:       "convert fenced code blocks with a language (```java) of the template to the code cells",
+						Destination: &fencedCodeFlag,
+					},
+					&cli.BoolFlag{
+						Name:        "strict",
+						Aliases:     []string{"s"},
+						Value:       cfg.Strict,
+						Usage:       "fail on unknown or malformed comments of the template and report all the problems",
+						Destination: &strictFlag,
+					},
+					&cli.BoolFlag{
+						Name:        "front-matter",
+						Value:       cfg.FrontMatter,
+						Usage:       "merge YAML front matter at the top of the template into the notebook metadata",
+						Destination: &frontMatterFlag,
+					},
+				}, outputFlags(&outputOpts)...),
+				Before: configure,
+				Action: func(c *cli.Context) error {
+					notebookPath := c.Args().First()
+
+					selector, err := runner.ParseSelector(runOnly)
+					if err != nil {
+						return err
+					}
+
+					opts := []runner.Option{
+						runner.WithTimeout(runTimeout),
+						runner.WithSelector(selector),
+					}
+					for _, r := range runRunners.Value() {
+						lang, cmd, err := runner.ParseCommand(r)
+						if err != nil {
+							return err
+						}
+						opts = append(opts, runner.WithCommand(lang, cmd))
+					}
+
+					serializerOpts := serializerOptions(fencedCodeFlag, strictFlag, frontMatterFlag)
+
+					return notecli.RunNotebook(notebookPath, outputOpts, prettyBookFlag, serializerOpts, opts...)
+				},
+			},
+			{
+				Name:     "watch",
+				Category: "template",
+				Description: "converts the template to the notebook and rebuilds it every time the template, " +
+					"its includes or the local files referenced from it are changed",
+				Usage: "watch --output destination.notebook <path to the template file>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:        "output",
+						Aliases:     []string{"o"},
+						Required:    true,
+						Usage:       "path to the notebook file to write",
+						Destination: &outputOpts.Path,
+					},
+					forceFlag(&outputOpts),
+					backupFlag(&outputOpts),
+					&cli.DurationFlag{
+						Name:        "debounce",
+						Value:       watch.DefaultDebounce,
+						Usage:       "time the files have to stay unchanged before the rebuild",
+						Destination: &watchDebounce,
+					},
+					&cli.BoolFlag{
+						Name:        "pretty",
+						Aliases:     []string{"p"},
+						Value:       cfg.Pretty,
+						Usage:       "pretty JSON output for notebook document",
+						Destination: &prettyBookFlag,
+					},
+					&cli.BoolFlag{
+						Name:        "fenced-code",
+						Aliases:     []string{"f"},
+						Value:       cfg.FencedCode,
+						Usage:       "convert fenced code blocks with a language (```java) to the code cells",
+						Destination: &fencedCodeFlag,
+					},
+					&cli.BoolFlag{
+						Name:        "strict",
+						Aliases:     []string{"s"},
+						Value:       cfg.Strict,
+						Usage:       "fail on unknown or malformed comments and report all the problems",
+						Destination: &strictFlag,
+					},
+					&cli.BoolFlag{
+						Name:        "front-matter",
+						Value:       cfg.FrontMatter,
+						Usage:       "merge YAML front matter at the top of the template into the notebook metadata",
+						Destination: &frontMatterFlag,
+					},
+				},
+				Before: configure,
+				Action: func(c *cli.Context) error {
+					templatePath := c.Args().First()
+					if templatePath == "" {
+						return cli.ShowSubcommandHelp(c)
+					}
+
+					opts := serializerOptions(fencedCodeFlag, strictFlag, frontMatterFlag)
+
+					ctx, stop := signal.NotifyContext(c.Context, os.Interrupt)
+					defer stop()
+
+					w := watch.New(watch.WithDebounce(watchDebounce))
+					return notecli.WatchTemplate(ctx, templatePath, outputOpts, prettyBookFlag, w, opts...)
+				},
+			},
+			{
+				Name:     "diff",
+				Category: "notebook",
+				Description: "reports added, removed, moved and modified cells of two notebook (or template) files, " +
+					"cells are aligned by the `id` metadata or by the content similarity",
+				Usage: "diff <path to the old notebook file> <path to the new notebook file>",
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{
+						Name:        "format",
+						Aliases:     []string{"f"},
+						Value:       notecli.ReportFormatText,
+						Usage:       "output format of the difference: text or json",
+						Destination: &diffFormat,
+					},
+				}, outputFlags(&outputOpts)...),
+				Before: configure,
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 2 {
+						return cli.ShowSubcommandHelp(c)
+					}
+
+					return notecli.DiffNotebooks(c.Args().Get(0), c.Args().Get(1), diffFormat, outputOpts)
+				},
+			},
+			{
+				Name:     "merge-driver",
+				Category: "notebook",
+				Description: "merges cells and metadata of the notebooks changed since the base one into ours file, " +
+					"conflicting cells get the conflict markers in the content. Configure it as the git merge driver " +
+					"with `git config merge.celli.driver \"celli merge-driver %O %A %B %P\"` " +
+					"and `*.javabook merge=celli` in .gitattributes",
+				Usage: "merge-driver <base file> <ours file> <theirs file> [path of the merged file]",
+				Flags: []cli.Flag{
+					&cli.PathFlag{
+						Name:        "output",
+						Aliases:     []string{"o"},
+						Usage:       "output file, - for stdout",
+						DefaultText: "ours file",
+						Destination: &outputOpts.Path,
+					},
+					forceFlag(&outputOpts),
+					backupFlag(&outputOpts),
+				},
+				Before: configure,
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 3 && c.NArg() != 4 {
+						return cli.ShowSubcommandHelp(c)
+					}
+
+					args := c.Args()
+					return notecli.MergeNotebooks(args.Get(0), args.Get(1), args.Get(2), args.Get(3), outputOpts)
+				},
+			},
+			{
+				Name:     "git-filter",
+				Category: "notebook",
+				Description: "stores templates in the git repository while the working tree holds the notebooks: " +
+					"clean converts the notebook to the template, smudge converts the template back to the notebook",
+				Usage: "git-filter clean | smudge | install",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "clean",
+						Usage:  "clean < notebook > template.md",
+						Before: configure,
+						Action: func(c *cli.Context) error {
+							return notecli.GitFilterClean()
+						},
+					},
+					{
+						Name:  "smudge",
+						Usage: "smudge [path of the file in the repository] < template.md > notebook",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:        "pretty",
+								Aliases:     []string{"p"},
+								Value:       cfg.Pretty,
+								Usage:       "pretty JSON output for notebook document",
+								Destination: &prettyBookFlag,
+							},
+						},
+						Before: configure,
+						Action: func(c *cli.Context) error {
+							return notecli.GitFilterSmudge(c.Args().First(), prettyBookFlag)
+						},
+					},
+					{
+						Name:  "install",
+						Usage: "configures the filter in the repository and assigns it to the notebook files in .gitattributes",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:        "command",
+								Value:       "celli",
+								Usage:       "celli executable run by git",
+								Destination: &gitFilterCommand,
+							},
+							&cli.StringSliceFlag{
+								Name:        "book-type",
+								Usage:       "book types which notebook files are filtered",
+								DefaultText: "all the supported book types",
+								Destination: &gitFilterBookTypes,
+							},
+						},
+						Action: func(c *cli.Context) error {
+							extensions, err := bookExtensions(gitFilterBookTypes.Value())
+							if err != nil {
+								return err
+							}
+
+							return notecli.InstallGitFilter(gitFilterCommand, extensions)
+						},
+					},
+				},
 			},
 			{
 				Name:        "convert",
 				Aliases:     []string{"c", "transform"},
 				Category:    "template",
 				Description: "converts existing notebook file to the template or existing template file to the notebook",
-				Usage:       "convert book2tpl | tpl2book <path to the file>",
+				Usage:       "convert book2tpl | tpl2book | ipynb2book | book2ipynb <path to the file, directory or glob>...",
 				Subcommands: []*cli.Command{
 					{
 						Name:    "book2tpl",
 						Aliases: []string{"b2t"},
-						Usage:   "book2tpl <path to the notebook file> > destination.md",
+						Usage:   "book2tpl [--output destination.md] <path to the notebook file>",
+						Flags: append([]cli.Flag{
+							&cli.BoolFlag{
+								Name:        "front-matter",
+								Value:       cfg.FrontMatter,
+								Usage:       "write notebook metadata as YAML front matter instead of the notebook comment",
+								Destination: &frontMatterFlag,
+							},
+						}, append(outputFlags(&outputOpts), batchFlags(&batchOpts)...)...),
+						Before: configure,
 						Action: func(c *cli.Context) error {
 							notebookPath := c.Args().First()
-							return notecli.ConvertToTemplate(notebookPath)
+
+							var opts []converter.Option
+							if frontMatterFlag {
+								opts = append(opts, converter.WithFrontMatter())
+							}
+
+							if isBatch(c, batchOpts) {
+								batchOpts.Force, batchOpts.Backup = outputOpts.Force, outputOpts.Backup
+								return notecli.ConvertToTemplateBatch(c.Args().Slice(), batchOpts, opts...)
+							}
+
+							return notecli.ConvertToTemplate(notebookPath, outputOpts, opts...)
 						},
 					},
 					{
 						Name:    "tpl2book",
 						Aliases: []string{"t2b"},
-						Usage:   "tpl2book <path to the template file> > destination.notebook",
-						Flags: []cli.Flag{
+						Usage:   "tpl2book [--output destination.notebook] <path to the template file>",
+						Flags: append([]cli.Flag{
 							&cli.BoolFlag{
 								Name:        "pretty",
 								Aliases:     []string{"p"},
-								Value:       false,
+								Value:       cfg.Pretty,
 								Usage:       "pretty JSON output for notebook document",
 								Destination: &prettyBookFlag,
 							},
-						},
+							&cli.BoolFlag{
+								Name:        "fenced-code",
+								Aliases:     []string{"f"},
+								Value:       cfg.FencedCode,
+								Usage:       "convert fenced code blocks with a language (```java) to the code cells",
+								Destination: &fencedCodeFlag,
+							},
+							&cli.BoolFlag{
+								Name:        "strict",
+								Aliases:     []string{"s"},
+								Value:       cfg.Strict,
+								Usage:       "fail on unknown or malformed comments and report all the problems",
+								Destination: &strictFlag,
+							},
+							&cli.BoolFlag{
+								Name:        "front-matter",
+								Value:       cfg.FrontMatter,
+								Usage:       "merge YAML front matter at the top of the template into the notebook metadata",
+								Destination: &frontMatterFlag,
+							},
+						}, append(outputFlags(&outputOpts), batchFlags(&batchOpts)...)...),
+						Before: configure,
 						Action: func(c *cli.Context) error {
 							templatePath := c.Args().First()
-							return notecli.ConvertToNotebook(templatePath, prettyBookFlag)
+							opts := serializerOptions(fencedCodeFlag, strictFlag, frontMatterFlag)
+
+							if isBatch(c, batchOpts) {
+								batchOpts.Pretty = prettyBookFlag
+								batchOpts.Force, batchOpts.Backup = outputOpts.Force, outputOpts.Backup
+								return notecli.ConvertToNotebookBatch(c.Args().Slice(), batchOpts, opts...)
+							}
+
+							return notecli.ConvertToNotebook(templatePath, outputOpts, prettyBookFlag, opts...)
+						},
+					},
+					{
+						Name:    "ipynb2book",
+						Aliases: []string{"i2b"},
+						Usage:   "ipynb2book [--output destination.notebook] <path to the Jupyter notebook file>",
+						Flags: append([]cli.Flag{
+							&cli.BoolFlag{
+								Name:        "pretty",
+								Aliases:     []string{"p"},
+								Value:       cfg.Pretty,
+								Usage:       "pretty JSON output for notebook document",
+								Destination: &prettyBookFlag,
+							},
+						}, append(outputFlags(&outputOpts), batchFlags(&batchOpts)...)...),
+						Before: configure,
+						Action: func(c *cli.Context) error {
+							ipynbPath := c.Args().First()
+
+							if isBatch(c, batchOpts) {
+								batchOpts.Pretty = prettyBookFlag
+								batchOpts.Force, batchOpts.Backup = outputOpts.Force, outputOpts.Backup
+								return notecli.ConvertIPYNBToNotebookBatch(c.Args().Slice(), batchOpts)
+							}
+
+							return notecli.ConvertIPYNBToNotebook(ipynbPath, outputOpts, prettyBookFlag)
+						},
+					},
+					{
+						Name:    "book2ipynb",
+						Aliases: []string{"b2i"},
+						Usage:   "book2ipynb [--output destination.ipynb] <path to the notebook file>",
+						Flags: append([]cli.Flag{
+							&cli.BoolFlag{
+								Name:        "pretty",
+								Aliases:     []string{"p"},
+								Value:       cfg.Pretty,
+								Usage:       "pretty JSON output for Jupyter notebook document",
+								Destination: &prettyBookFlag,
+							},
+						}, append(outputFlags(&outputOpts), batchFlags(&batchOpts)...)...),
+						Before: configure,
+						Action: func(c *cli.Context) error {
+							notebookPath := c.Args().First()
+
+							if isBatch(c, batchOpts) {
+								batchOpts.Pretty = prettyBookFlag
+								batchOpts.Force, batchOpts.Backup = outputOpts.Force, outputOpts.Backup
+								return notecli.ConvertNotebookToIPYNBBatch(c.Args().Slice(), batchOpts)
+							}
+
+							return notecli.ConvertNotebookToIPYNB(notebookPath, outputOpts, prettyBookFlag)
 						},
 					},
 				},
 			},
 		},
 	}
+
 	if err := app.Run(os.Args); err != nil {
 		logrus.Fatal(err)
 	}
 }
 
-func createNewSubcommands() []*cli.Command {
-	notebookTypes := types.SupportedBookTypes()
+func batchFlags(batchOpts *notecli.BatchOptions) []cli.Flag {
+	return []cli.Flag{
+		&cli.PathFlag{
+			Name:        "out-dir",
+			Aliases:     []string{"d"},
+			Usage:       "convert directories and globs mirroring their tree into the directory",
+			Destination: &batchOpts.OutDir,
+		},
+		&cli.IntFlag{
+			Name:        "jobs",
+			Aliases:     []string{"j"},
+			Usage:       "number of concurrent conversions",
+			DefaultText: "number of CPUs",
+			Destination: &batchOpts.Jobs,
+		},
+		&cli.StringFlag{
+			Name:        "book-type",
+			Usage:       "book type that defines the extension of the notebook files created",
+			Value:       batchOpts.BookType,
+			Destination: &batchOpts.BookType,
+		},
+	}
+}
+
+func outputFlags(out *notecli.OutputOptions) []cli.Flag {
+	return []cli.Flag{
+		&cli.PathFlag{
+			Name:        "output",
+			Aliases:     []string{"o"},
+			Usage:       "output file, - for stdout",
+			DefaultText: "stdout",
+			Destination: &out.Path,
+		},
+		forceFlag(out),
+		backupFlag(out),
+	}
+}
+
+func forceFlag(out *notecli.OutputOptions) cli.Flag {
+	return &cli.BoolFlag{
+		Name:        "force",
+		Usage:       "overwrite existing output files",
+		Destination: &out.Force,
+	}
+}
+
+func backupFlag(out *notecli.OutputOptions) cli.Flag {
+	return &cli.BoolFlag{
+		Name:        "backup",
+		Usage:       "keep a copy of the overwritten output files with the .bak extension",
+		Destination: &out.Backup,
+	}
+}
+
+// serializerOptions returns template serializer options of the flags.
+func serializerOptions(fencedCode, strict, frontMatter bool) []serializer.Option {
+	var opts []serializer.Option
+	if fencedCode {
+		opts = append(opts, serializer.WithFencedCode())
+	}
+	if strict {
+		opts = append(opts, serializer.WithStrict())
+	}
+	if frontMatter {
+		opts = append(opts, serializer.WithFrontMatter())
+	}
+
+	return opts
+}
+
+// isBatch reports whether the conversion is run for the directories, globs or several files.
+func isBatch(c *cli.Context, batchOpts notecli.BatchOptions) bool {
+	return batchOpts.OutDir != "" || notecli.IsBatch(c.Args().Slice())
+}
+
+func createNewSubcommands(out *notecli.OutputOptions, templatePaths *cli.StringSlice) []*cli.Command {
+	notebookTypes := supportedBookTypes()
 	cmds := make([]*cli.Command, len(notebookTypes))
 
-	var templateDest string
 	for i := range notebookTypes {
+		bookType := notebookTypes[i]
 		cmds[i] = &cli.Command{
-			Name: notebookTypes[i],
-			Flags: []cli.Flag{
-				&cli.PathFlag{
-					Name:        "output",
-					Aliases:     []string{"o", "dest", "dst"},
-					Usage:       "output file or folder to save template data",
-					DefaultText: "template.md in the current directory",
-					Destination: &templateDest,
-					Value:       "./",
-				},
-			},
+			Name:  bookType,
+			Flags: newTemplateFlags(out, templatePaths),
 			Action: func(c *cli.Context) error {
-				return notecli.CreateTemplate(notebookTypes[i], templateDest)
+				return createTemplate(bookType, *out, *templatePaths)
 			},
 		}
 	}
 
 	return cmds
 }
+
+func newTemplateFlags(out *notecli.OutputOptions, templatePaths *cli.StringSlice) []cli.Flag {
+	return []cli.Flag{
+		&cli.PathFlag{
+			Name:        "output",
+			Aliases:     []string{"o", "dest", "dst"},
+			Usage:       "output file or folder to save template data, - for stdout",
+			DefaultText: "template.md in the current directory",
+			Destination: &out.Path,
+			Value:       "./",
+		},
+		forceFlag(out),
+		backupFlag(out),
+		&cli.StringSliceFlag{
+			Name:    "template",
+			Aliases: []string{"t"},
+			Usage: "user template file or folder to execute instead of the built-in one, " +
+				"the next ones can override its blocks",
+			Destination: templatePaths,
+		},
+	}
+}
+
+func createTemplate(bookType string, out notecli.OutputOptions, templatePaths cli.StringSlice) error {
+	var opts []template.Option
+	if paths := templatePaths.Value(); len(paths) != 0 {
+		opts = append(opts, template.WithTemplatePaths(paths...))
+	}
+
+	return notecli.CreateTemplate(bookType, out, opts...)
+}
+
+// bookExtensions returns notebook file extensions of the book types or of all the supported ones.
+func bookExtensions(bookTypes []string) ([]string, error) {
+	if len(bookTypes) == 0 {
+		bookTypes = supportedBookTypes()
+	}
+
+	extensions := make([]string, 0, len(bookTypes))
+	for _, bookType := range bookTypes {
+		ext, err := template.BookExtension(types.BookType(strings.ToLower(bookType)))
+		if err != nil {
+			return nil, err
+		}
+		extensions = append(extensions, ext)
+	}
+
+	return extensions, nil
+}
+
+// supportedBookTypes returns built-in and user defined book types,
+// or only built-in ones if the user definitions could not be read.
+func supportedBookTypes() []string {
+	bookTypes, err := template.SupportedBookTypes()
+	if err != nil {
+		logrus.Warn(err)
+		return types.SupportedBookTypes()
+	}
+
+	return bookTypes
+}
+
+// configDefault reports whether the command has the flag and it is not set by any of its names,
+// so it takes the configured value.
+func configDefault(c *cli.Context, name string) bool {
+	if c.Command == nil {
+		return false
+	}
+
+	for _, flag := range c.Command.Flags {
+		names := flag.Names()
+		if names[0] != name {
+			continue
+		}
+
+		for _, n := range names {
+			if c.IsSet(n) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
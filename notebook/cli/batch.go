@@ -0,0 +1,323 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/MonkeyBuisness/celli/notebook/converter"
+	"github.com/MonkeyBuisness/celli/notebook/serializer"
+	"github.com/MonkeyBuisness/celli/notebook/template"
+	"github.com/MonkeyBuisness/celli/notebook/types"
+)
+
+const (
+	ipynbFileExt = ".ipynb"
+	globMeta     = `*?[`
+)
+
+// BatchOptions represents batch conversion configuration model.
+type BatchOptions struct {
+	// OutDir is the directory the input tree is mirrored to.
+	OutDir string
+	// Jobs is the number of concurrent conversions (number of CPUs by default).
+	Jobs int
+	// BookType defines the extension of the notebook files created.
+	BookType string
+	// Pretty enables pretty JSON output.
+	Pretty bool
+	// Force allows to overwrite the existing output files.
+	Force bool
+	// Backup keeps copies of the overwritten output files with the .bak extension.
+	Backup bool
+}
+
+type batchTask struct {
+	input  string
+	output string
+}
+
+type batchResult struct {
+	task batchTask
+	err  error
+}
+
+type convertFunc func(path string, w io.Writer) error
+
+// IsBatch reports whether the inputs have to be converted in batch: there are several of them,
+// or some of them are directories or globs.
+func IsBatch(inputs []string) bool {
+	if len(inputs) > 1 {
+		return true
+	}
+
+	for _, input := range inputs {
+		if strings.ContainsAny(input, globMeta) {
+			return true
+		}
+
+		if info, err := os.Stat(input); err == nil && info.IsDir() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ConvertToTemplateBatch converts notebook files of the inputs to the templates in the output directory.
+func ConvertToTemplateBatch(inputs []string, batch BatchOptions, opt ...converter.Option) error {
+	extensions, err := template.BookExtensions()
+	if err != nil {
+		return err
+	}
+
+	isNotebook := func(path string) bool {
+		_, ok := extensions[strings.ToLower(filepath.Ext(path))]
+		return ok
+	}
+
+	return runBatch(inputs, batch, isNotebook, templateFileExt, func(path string, w io.Writer) error {
+		return convertToTemplate(path, w, opt...)
+	})
+}
+
+// ConvertToNotebookBatch converts template files of the inputs to the notebooks in the output directory.
+func ConvertToNotebookBatch(inputs []string, batch BatchOptions, opt ...serializer.Option) error {
+	ext, err := template.BookExtension(types.BookType(strings.ToLower(batch.BookType)))
+	if err != nil {
+		return err
+	}
+
+	return runBatch(inputs, batch, hasExt(templateFileExt), ext, func(path string, w io.Writer) error {
+		return convertToNotebook(path, w, batch.Pretty, opt...)
+	})
+}
+
+// ConvertIPYNBToNotebookBatch converts Jupyter notebook files of the inputs to the notebooks in the output directory.
+func ConvertIPYNBToNotebookBatch(inputs []string, batch BatchOptions) error {
+	ext, err := template.BookExtension(types.BookType(strings.ToLower(batch.BookType)))
+	if err != nil {
+		return err
+	}
+
+	return runBatch(inputs, batch, hasExt(ipynbFileExt), ext, func(path string, w io.Writer) error {
+		return convertIPYNBToNotebook(path, w, batch.Pretty)
+	})
+}
+
+// ConvertNotebookToIPYNBBatch converts notebook files of the inputs to the Jupyter notebooks in the output directory.
+func ConvertNotebookToIPYNBBatch(inputs []string, batch BatchOptions) error {
+	extensions, err := template.BookExtensions()
+	if err != nil {
+		return err
+	}
+
+	isNotebook := func(path string) bool {
+		_, ok := extensions[strings.ToLower(filepath.Ext(path))]
+		return ok
+	}
+
+	return runBatch(inputs, batch, isNotebook, ipynbFileExt, func(path string, w io.Writer) error {
+		return convertNotebookToIPYNB(path, w, batch.Pretty)
+	})
+}
+
+// runBatch converts the matching files of the inputs with the bounded worker pool
+// and prints the summary of the conversions.
+func runBatch(inputs []string, batch BatchOptions, match func(path string) bool,
+	outExt string, convert convertFunc) error {
+	if batch.OutDir == "" {
+		return fmt.Errorf("output directory is not provided")
+	}
+
+	tasks, err := collectBatchTasks(inputs, batch.OutDir, match, outExt)
+	if err != nil {
+		return err
+	}
+
+	if len(tasks) == 0 {
+		return fmt.Errorf("no files to convert found in %s", strings.Join(inputs, ", "))
+	}
+
+	jobs := batch.Jobs
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+
+	results := make([]batchResult, len(tasks))
+	taskIndices := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < jobs && i < len(tasks); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range taskIndices {
+				results[index] = batchResult{
+					task: tasks[index],
+					err:  convertFile(tasks[index], batch, convert),
+				}
+			}
+		}()
+	}
+
+	for i := range tasks {
+		taskIndices <- i
+	}
+	close(taskIndices)
+	wg.Wait()
+
+	return writeBatchSummary(os.Stdout, results)
+}
+
+func convertFile(task batchTask, batch BatchOptions, convert convertFunc) error {
+	var buf bytes.Buffer
+	if err := convert(task.input, &buf); err != nil {
+		return err
+	}
+
+	return writeOutputFile(OutputOptions{
+		Path:   task.output,
+		Force:  batch.Force,
+		Backup: batch.Backup,
+	}, buf.Bytes())
+}
+
+func writeBatchSummary(w io.Writer, results []batchResult) error {
+	failed := 0
+	for _, result := range results {
+		if result.err != nil {
+			failed++
+			fmt.Fprintf(w, "failed  %s: %v\n", result.task.input, result.err)
+			continue
+		}
+		fmt.Fprintf(w, "ok      %s -> %s\n", result.task.input, result.task.output)
+	}
+	fmt.Fprintf(w, "%d file(s) converted, %d failed\n", len(results)-failed, failed)
+
+	if failed != 0 {
+		return fmt.Errorf("%d of %d file(s) failed", failed, len(results))
+	}
+
+	return nil
+}
+
+// collectBatchTasks expands the inputs to the files and mirrors their paths into the output directory.
+//
+// Directories are walked recursively and only the matching files are taken from them,
+// files that are provided explicitly are always taken.
+// Different inputs mirrored to the same output file are reported as an error.
+func collectBatchTasks(inputs []string, outDir string, match func(path string) bool,
+	outExt string) ([]batchTask, error) {
+	var tasks []batchTask
+	seen := make(map[string]struct{})
+	outputs := make(map[string]string)
+
+	addTask := func(root, path string) error {
+		if _, ok := seen[filepath.Clean(path)]; ok {
+			return nil
+		}
+		seen[filepath.Clean(path)] = struct{}{}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		output := filepath.Join(outDir, strings.TrimSuffix(rel, filepath.Ext(rel))+outExt)
+		if input, ok := outputs[output]; ok {
+			return fmt.Errorf("%s and %s are converted to the same file %s", input, path, output)
+		}
+		outputs[output] = path
+
+		tasks = append(tasks, batchTask{
+			input:  path,
+			output: output,
+		})
+
+		return nil
+	}
+
+	for _, input := range inputs {
+		root, paths := filepath.Dir(input), []string{input}
+		if strings.ContainsAny(input, globMeta) {
+			root = globRoot(input)
+
+			var err error
+			if paths, err = filepath.Glob(input); err != nil {
+				return nil, fmt.Errorf("invalid glob %s: %v", input, err)
+			}
+		}
+
+		for _, path := range paths {
+			info, err := os.Stat(path)
+			if err != nil {
+				return nil, err
+			}
+
+			if !info.IsDir() {
+				if err := addTask(root, path); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			// the directory itself is the root of the tree if it is provided explicitly.
+			dirRoot := root
+			if path == input {
+				dirRoot = path
+			}
+
+			err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+
+				// skip the output directory if it is inside the input tree.
+				if d.IsDir() && sameDir(p, outDir) {
+					return filepath.SkipDir
+				}
+
+				if d.IsDir() || !match(p) {
+					return nil
+				}
+
+				return addTask(dirRoot, p)
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return tasks, nil
+}
+
+// globRoot returns the directory part of the glob pattern that does not contain meta characters.
+func globRoot(pattern string) string {
+	dir := filepath.Dir(pattern)
+	for strings.ContainsAny(dir, globMeta) {
+		dir = filepath.Dir(dir)
+	}
+
+	return dir
+}
+
+func sameDir(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+
+	return errA == nil && errB == nil && absA == absB
+}
+
+func hasExt(ext string) func(path string) bool {
+	return func(path string) bool {
+		return strings.EqualFold(filepath.Ext(path), ext)
+	}
+}
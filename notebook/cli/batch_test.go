@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MonkeyBuisness/celli/notebook/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_collectBatchTasks(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"course/01.md":          "",
+		"course/part/02.md":     "",
+		"course/part/notes":     "",
+		"extra/03.md":           "",
+		"extra/04.txt":          "",
+		"course/out/ignored.md": "",
+	})
+	outDir := filepath.Join(root, "course", "out")
+
+	tasks, err := collectBatchTasks([]string{
+		filepath.Join(root, "course"),
+		filepath.Join(root, "ex*", "*.md"),
+		filepath.Join(root, "extra", "04.txt"),
+	}, outDir, hasExt(templateFileExt), ".javabook")
+	require.NoError(t, err)
+	require.Equal(t, []batchTask{
+		{input: filepath.Join(root, "course", "01.md"), output: filepath.Join(outDir, "01.javabook")},
+		{input: filepath.Join(root, "course", "part", "02.md"), output: filepath.Join(outDir, "part", "02.javabook")},
+		{input: filepath.Join(root, "extra", "03.md"), output: filepath.Join(outDir, "extra", "03.javabook")},
+		{input: filepath.Join(root, "extra", "04.txt"), output: filepath.Join(outDir, "04.javabook")},
+	}, tasks)
+
+	t.Run("same output", func(t *testing.T) {
+		root := testutil.WriteFiles(t, map[string]string{
+			"course/01.md": "",
+			"extra/01.md":  "",
+		})
+		first, second := filepath.Join(root, "course", "01.md"), filepath.Join(root, "extra", "01.md")
+
+		_, err := collectBatchTasks([]string{first, second}, outDir, hasExt(templateFileExt), ".javabook")
+		require.EqualError(t, err, fmt.Sprintf("%s and %s are converted to the same file %s",
+			first, second, filepath.Join(outDir, "01.javabook")))
+	})
+}
+
+func Test_runBatch(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"01.md":      "first",
+		"part/02.md": "second",
+		"part/03.md": "fail",
+	})
+	outDir := filepath.Join(t.TempDir(), "out")
+
+	err := runBatch([]string{root}, BatchOptions{OutDir: outDir, Jobs: 2}, hasExt(templateFileExt), ".txt",
+		func(path string, w io.Writer) error {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			if string(data) == "fail" {
+				return errors.New("could not convert")
+			}
+
+			_, err = w.Write(bytes.ToUpper(data))
+			return err
+		})
+	require.EqualError(t, err, "1 of 3 file(s) failed")
+
+	data, err := os.ReadFile(filepath.Join(outDir, "part", "02.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "SECOND", string(data))
+
+	_, err = os.Stat(filepath.Join(outDir, "part", "03.txt"))
+	require.True(t, os.IsNotExist(err))
+}
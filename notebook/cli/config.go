@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/MonkeyBuisness/celli/notebook/config"
+)
+
+// currentConfig is the configuration used by the commands.
+var currentConfig = config.Default()
+
+// Configure sets the configuration used by the commands.
+func Configure(cfg *config.Config) {
+	currentConfig = cfg
+}
+
+// ShowConfig writes the effective configuration as YAML to the output.
+func ShowConfig(cfg *config.Config, cfgPath string, out OutputOptions) error {
+	data, err := cfg.Marshal()
+	if err != nil {
+		return err
+	}
+
+	if cfgPath == "" {
+		cfgPath = "not found, defaults are used"
+	}
+
+	return writeOutput(out, func(w io.Writer) error {
+		_, err := fmt.Fprintf(w, "# %s: %s\n%s", config.FileName, cfgPath, data)
+		return err
+	})
+}
@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MonkeyBuisness/celli/notebook/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ShowConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	require.NoError(t, ShowConfig(config.Default(), "", OutputOptions{Path: path}))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(data), "# .celli.yaml: not found, defaults are used\n"), string(data))
+
+	err = ShowConfig(config.Default(), "", OutputOptions{Path: path})
+	require.EqualError(t, err, path+" already exists, use --force to overwrite it")
+	require.NoError(t, ShowConfig(config.Default(), ".celli.yaml", OutputOptions{Path: path, Force: true}))
+}
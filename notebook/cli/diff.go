@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/MonkeyBuisness/celli/notebook/diff"
+)
+
+// DiffNotebooks writes the semantic difference of two notebook (or template) files
+// in the text or JSON format to the output.
+func DiffNotebooks(oldPath, newPath, format string, out OutputOptions) error {
+	oldNotebook, err := readNotebook(oldPath)
+	if err != nil {
+		return fmt.Errorf("%s: %v", oldPath, err)
+	}
+
+	newNotebook, err := readNotebook(newPath)
+	if err != nil {
+		return fmt.Errorf("%s: %v", newPath, err)
+	}
+
+	result := diff.Compare(oldNotebook, newNotebook)
+
+	return writeOutput(out, func(w io.Writer) error {
+		return writeDiff(w, result, format)
+	})
+}
+
+func writeDiff(w io.Writer, result *diff.Result, format string) error {
+	switch format {
+	case ReportFormatText, "":
+		return writeTextDiff(w, result)
+	case ReportFormatJSON:
+		return writeIndentedJSON(w, result)
+	}
+
+	return fmt.Errorf("unsupported diff format %q", format)
+}
+
+func writeTextDiff(w io.Writer, result *diff.Result) error {
+	var b strings.Builder
+	if len(result.Metadata) != 0 {
+		b.WriteString("notebook metadata:\n")
+		writeMetadataChanges(&b, result.Metadata)
+	}
+
+	for i := range result.Cells {
+		c := &result.Cells[i]
+
+		switch c.Kind {
+		case diff.ChangeAdded:
+			fmt.Fprintf(&b, "cell %d added%s\n", c.NewIndex, cellIDSuffix(c.ID))
+			writePrefixedLines(&b, "+", c.Content)
+		case diff.ChangeRemoved:
+			fmt.Fprintf(&b, "cell %d removed%s\n", c.OldIndex, cellIDSuffix(c.ID))
+			writePrefixedLines(&b, "-", c.Content)
+		case diff.ChangeMoved:
+			fmt.Fprintf(&b, "cell %d moved to %d%s\n", c.OldIndex, c.NewIndex, cellIDSuffix(c.ID))
+		case diff.ChangeModified:
+			position := fmt.Sprintf("cell %d", c.NewIndex)
+			if c.Moved {
+				position = fmt.Sprintf("cell %d moved to %d and", c.OldIndex, c.NewIndex)
+			} else if c.OldIndex != c.NewIndex {
+				position = fmt.Sprintf("cell %d (was %d)", c.NewIndex, c.OldIndex)
+			}
+			fmt.Fprintf(&b, "%s modified%s\n", position, cellIDSuffix(c.ID))
+
+			b.WriteString(c.ContentDiff)
+			if len(c.Metadata) != 0 {
+				b.WriteString("metadata:\n")
+				writeMetadataChanges(&b, c.Metadata)
+			}
+			if c.OutputsChanged {
+				b.WriteString("outputs changed\n")
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+func writeMetadataChanges(b *strings.Builder, changes []diff.MetadataChange) {
+	for _, c := range changes {
+		switch {
+		case c.Old == nil:
+			fmt.Fprintf(b, "  + %s: %s\n", c.Key, jsonValue(c.New))
+		case c.New == nil:
+			fmt.Fprintf(b, "  - %s: %s\n", c.Key, jsonValue(c.Old))
+		default:
+			fmt.Fprintf(b, "  ~ %s: %s -> %s\n", c.Key, jsonValue(c.Old), jsonValue(c.New))
+		}
+	}
+}
+
+func writePrefixedLines(b *strings.Builder, prefix, content string) {
+	if content == "" {
+		return
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+		b.WriteString(prefix)
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+}
+
+func cellIDSuffix(id string) string {
+	if id == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(" [id %s]", id)
+}
+
+func jsonValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(data)
+}
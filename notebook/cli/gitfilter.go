@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/MonkeyBuisness/celli/notebook/converter"
+	"github.com/MonkeyBuisness/celli/notebook/serializer"
+	"github.com/MonkeyBuisness/celli/notebook/types"
+	"github.com/MonkeyBuisness/celli/notebook/utils"
+)
+
+const (
+	gitFilterName      = "celli"
+	gitAttributesFile  = ".gitattributes"
+	defaultGitFilePath = "notebook" + templateFileExt
+)
+
+// GitFilterClean converts the notebook on stdin to the template on stdout,
+// so the repository stores the template (git clean filter).
+func GitFilterClean() error {
+	return gitFilterClean(os.Stdin, os.Stdout)
+}
+
+func gitFilterClean(r io.Reader, w io.Writer) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	// empty files stay empty.
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+
+	// smudge parses the template without the fenced code option, so the code cells are never fenced.
+	template, err := converter.Proceed(bytes.NewReader(data), converter.WithoutFencedCode())
+	if err != nil {
+		return fmt.Errorf("could not convert notebook data: %v", err)
+	}
+
+	_, err = w.Write(template)
+
+	return err
+}
+
+// GitFilterSmudge converts the template on stdin to the notebook on stdout,
+// so the working tree holds the notebook (git smudge filter).
+//
+// The path is the path of the file in the repository (%f), includes are resolved relative to it.
+func GitFilterSmudge(path string, pretty bool, opt ...serializer.Option) error {
+	return gitFilterSmudge(os.Stdin, os.Stdout, path, pretty, opt...)
+}
+
+func gitFilterSmudge(r io.Reader, w io.Writer, path string, pretty bool, opt ...serializer.Option) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+
+	if path == "" {
+		path = defaultGitFilePath
+	}
+
+	notebookData, err := serializeTemplateSource(bytes.NewReader(data), path, opt...)
+	if err != nil {
+		return err
+	}
+
+	notebookJSON, err := json.Marshal(notebookData)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, notebookJSON, pretty)
+}
+
+// InstallGitFilter configures the celli filter in the git repository of the working directory
+// and assigns it to the notebook files of the extensions in .gitattributes.
+//
+// The command is the celli executable used by git.
+func InstallGitFilter(command string, extensions []string) error {
+	if len(extensions) == 0 {
+		return errors.New("notebook file extensions are not provided")
+	}
+
+	root, err := git("rev-parse", "--show-toplevel")
+	if err != nil {
+		return err
+	}
+
+	configs := [][2]string{
+		{"filter." + gitFilterName + ".clean", command + " git-filter clean"},
+		{"filter." + gitFilterName + ".smudge", command + " git-filter smudge %f"},
+	}
+	for _, cfg := range configs {
+		if _, err := git("config", cfg[0], cfg[1]); err != nil {
+			return err
+		}
+	}
+
+	return addGitAttributes(filepath.Join(root, gitAttributesFile), extensions)
+}
+
+// addGitAttributes appends filter attributes of the extensions that are not there yet.
+func addGitAttributes(path string, extensions []string) error {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not read %s: %v", gitAttributesFile, err)
+	}
+
+	existing := make(map[string]struct{})
+	for _, line := range strings.Split(string(data), "\n") {
+		existing[strings.Join(strings.Fields(line), " ")] = struct{}{}
+	}
+
+	sorted := append([]string{}, extensions...)
+	sort.Strings(sorted)
+
+	var b strings.Builder
+	b.Write(data)
+	if len(data) != 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		b.WriteString("\n")
+	}
+
+	added := false
+	for _, ext := range sorted {
+		line := fmt.Sprintf("*%s filter=%s", ext, gitFilterName)
+		if _, ok := existing[line]; ok {
+			continue
+		}
+
+		b.WriteString(line)
+		b.WriteString("\n")
+		added = true
+	}
+
+	if !added {
+		return nil
+	}
+
+	if err := utils.WriteFileAtomic(path, []byte(b.String()), types.DefaultFileMode); err != nil {
+		return fmt.Errorf("could not write %s: %v", gitAttributesFile, err)
+	}
+
+	return nil
+}
+
+func git(args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...) // #nosec G204
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), msg)
+		}
+
+		return "", fmt.Errorf("git %s: %v", strings.Join(args, " "), err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MonkeyBuisness/celli/notebook/lock"
+	"github.com/MonkeyBuisness/celli/notebook/types"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_gitFilter_RoundTrip(t *testing.T) {
+	notebook := `{
+		"metadata": {"created": "2021-12-06", "version": 1.0},
+		"cells": [
+			{"languageId": "markdown", "content": "# Title\n\ntext\n\n", "kind": 1},
+			{"languageId": "java", "content": "class Main {}\n", "kind": 2, "metadata": {"is-executable": "false"},
+				"outputs": [{"items": [{"mime": "application/vnd.code.notebook.stdout", "data": "ok"}]}]},
+			{"languageId": "markdown", "content": "  ## License  ", "kind": 1}
+		]
+	}`
+
+	var template bytes.Buffer
+	require.NoError(t, gitFilterClean(strings.NewReader(notebook), &template))
+
+	// the template stored in the repository is stable after the checkout.
+	for _, pretty := range []bool{false, true} {
+		var smudged, cleaned bytes.Buffer
+		require.NoError(t, gitFilterSmudge(bytes.NewReader(template.Bytes()), &smudged, "book.javabook", pretty))
+		require.NoError(t, gitFilterClean(&smudged, &cleaned))
+		require.Equal(t, template.String(), cleaned.String())
+	}
+}
+
+func Test_gitFilter_CodeSource(t *testing.T) {
+	t.Run("edited uri content", func(t *testing.T) {
+		notebook := fmt.Sprintf(`{"cells": [{"languageId": "java", "content": "class Main { int x; }", "kind": 2,
+			"metadata": {"celli": {"source": {"comment": "code", "style": "json", "uri": "file://Main.java", "checksum": %q}}}}]}`,
+			lock.Hash([]byte("class Main {}")))
+
+		var template, smudged bytes.Buffer
+		require.NoError(t, gitFilterClean(strings.NewReader(notebook), &template))
+		require.NotContains(t, template.String(), "file://Main.java")
+
+		// the file of the uri does not exist, so the edited content has to be kept in the template.
+		require.NoError(t, gitFilterSmudge(bytes.NewReader(template.Bytes()), &smudged,
+			filepath.Join(t.TempDir(), "book.javabook"), false))
+		require.Contains(t, smudged.String(), `"content":"class Main { int x; }"`)
+	})
+	t.Run("fenced code", func(t *testing.T) {
+		notebook := `{"cells": [{"languageId": "java", "content": "class Main {}", "kind": 2,
+			"metadata": {"celli": {"source": {"style": "fence"}}}}]}`
+
+		var template, smudged bytes.Buffer
+		require.NoError(t, gitFilterClean(strings.NewReader(notebook), &template))
+		require.NoError(t, gitFilterSmudge(bytes.NewReader(template.Bytes()), &smudged, "book.javabook", false))
+
+		var data types.NotebookData
+		require.NoError(t, json.Unmarshal(smudged.Bytes(), &data))
+		require.Len(t, data.Cells, 1)
+		require.Equal(t, types.NotebookCellKindCode, data.Cells[0].Kind)
+		require.Equal(t, "class Main {}", data.Cells[0].Content)
+	})
+}
+
+func Test_gitFilter_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, gitFilterClean(strings.NewReader(""), &buf))
+	require.NoError(t, gitFilterSmudge(strings.NewReader("\n"), &buf, "", false))
+	require.Empty(t, buf.String())
+}
+
+func Test_addGitAttributes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), gitAttributesFile)
+	require.NoError(t, os.WriteFile(path, []byte("*.png binary"), 0o600))
+
+	require.NoError(t, addGitAttributes(path, []string{".kotlinbook", ".javabook"}))
+	require.NoError(t, addGitAttributes(path, []string{".javabook"}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "*.png binary\n*.javabook filter=celli\n*.kotlinbook filter=celli\n", string(data))
+}
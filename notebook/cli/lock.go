@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/MonkeyBuisness/celli/notebook/lock"
+	"github.com/MonkeyBuisness/celli/notebook/serializer"
+	"github.com/MonkeyBuisness/celli/notebook/types"
+	"github.com/MonkeyBuisness/celli/notebook/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// LockTemplate records hashes of all the remote URIs referenced from the template into celli.lock
+// next to the template (the one later conversions enforce).
+//
+// URIs the template does not reference anymore are removed from the lockfile
+// unless the other templates of the directory reference them.
+//
+// Unlike the other outputs the lockfile is always updated in place (no --output, --force and --backup),
+// since it is shared by the templates of the directory and enforced only there.
+func LockTemplate(templatePath string) error {
+	lockPath := defaultLockPath(templatePath)
+
+	file, err := openInput(templatePath)
+	if err != nil {
+		return fmt.Errorf("could not open template file: %v", err)
+	}
+	defer utils.Close(file)
+
+	uriResolver, err := currentConfig.NewResolver()
+	if err != nil {
+		return err
+	}
+	recorder := lock.NewRecorder(uriResolver)
+
+	serializers, err := commentSerializers()
+	if err != nil {
+		return err
+	}
+
+	s := serializer.New()
+	if _, err := s.SerializeNotebook(file,
+		serializer.WithSourceName(templatePath),
+		serializer.WithIncludes(),
+		serializer.WithURIResolver(recorder),
+		serializer.WithCommentSerializer(serializers...),
+	); err != nil {
+		return fmt.Errorf("could not serialize notebook data: %v", err)
+	}
+
+	lockFile, err := lock.ReadIfExists(lockPath)
+	if err != nil {
+		return fmt.Errorf("could not read lockfile: %v", err)
+	}
+	if lockFile == nil {
+		lockFile = lock.NewFile()
+	}
+
+	hashes := recorder.Hashes()
+	uris := make([]string, 0, len(hashes))
+	for uri := range hashes {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+
+	for _, uri := range uris {
+		if prevHash, ok := lockFile.URIs[uri]; ok && prevHash != hashes[uri] {
+			logrus.Warnf("%s: hash is changed from %s to %s", uri, prevHash, hashes[uri])
+		}
+	}
+	lockFile.Record(filepath.Base(templatePath), hashes)
+
+	if err := lockFile.Write(lockPath); err != nil {
+		return fmt.Errorf("could not write lockfile: %v", err)
+	}
+
+	return nil
+}
+
+// templateResolver returns URI resolver that enforces lockfile next to the template if it exists.
+func templateResolver(templatePath string) (types.URIResolver, error) {
+	uriResolver, err := currentConfig.NewResolver()
+	if err != nil {
+		return nil, err
+	}
+
+	lockFile, err := lock.ReadIfExists(defaultLockPath(templatePath))
+	if err != nil {
+		return nil, fmt.Errorf("could not read lockfile: %v", err)
+	}
+
+	if lockFile == nil {
+		return uriResolver, nil
+	}
+
+	return lock.NewEnforcer(uriResolver, lockFile), nil
+}
+
+func defaultLockPath(templatePath string) string {
+	return filepath.Join(filepath.Dir(templatePath), lock.FileName)
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MonkeyBuisness/celli/notebook/internal/testutil"
+	"github.com/MonkeyBuisness/celli/notebook/lock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockTemplate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "class %s {}", strings.TrimSuffix(path.Base(r.URL.Path), ".java"))
+	}))
+	defer srv.Close()
+
+	codeComment := func(uri string) string {
+		return fmt.Sprintf("<!-- code:{\"lang\": \"java\", \"uri\": %q} -->\n", uri)
+	}
+	uriA, uriB, uriShared := srv.URL+"/A.java", srv.URL+"/B.java", srv.URL+"/Shared.java"
+	root := testutil.WriteFiles(t, map[string]string{
+		"a.md": codeComment(uriA) + codeComment(uriShared),
+		"b.md": codeComment(uriB) + codeComment(uriShared),
+	})
+	pathA, pathB := filepath.Join(root, "a.md"), filepath.Join(root, "b.md")
+
+	stale := lock.NewFile()
+	stale.Record("a.md", map[string]string{"https://example.com/Removed.java": lock.Hash([]byte("removed"))})
+	stale.URIs["https://example.com/Manual.java"] = lock.Hash([]byte("manual"))
+	require.NoError(t, stale.Write(defaultLockPath(pathA)))
+
+	require.NoError(t, LockTemplate(pathA))
+	require.NoError(t, LockTemplate(pathB))
+
+	lockFile, err := lock.Read(defaultLockPath(pathA))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		uriA:                              lock.Hash([]byte("class A {}")),
+		uriB:                              lock.Hash([]byte("class B {}")),
+		uriShared:                         lock.Hash([]byte("class Shared {}")),
+		"https://example.com/Manual.java": lock.Hash([]byte("manual")),
+	}, lockFile.URIs)
+
+	// the lockfile of the directory is enforced for every template.
+	_, err = serializeTemplate(pathA)
+	require.NoError(t, err)
+
+	// the uri of the other template is kept when the template stops referencing it.
+	require.NoError(t, os.WriteFile(pathA, []byte(codeComment(uriA)), 0o600))
+	require.NoError(t, os.WriteFile(pathB, []byte(codeComment(uriB)), 0o600))
+	require.NoError(t, LockTemplate(pathA))
+
+	lockFile, err = lock.Read(defaultLockPath(pathA))
+	require.NoError(t, err)
+	require.Contains(t, lockFile.URIs, uriShared)
+
+	require.NoError(t, LockTemplate(pathB))
+
+	lockFile, err = lock.Read(defaultLockPath(pathA))
+	require.NoError(t, err)
+	require.NotContains(t, lockFile.URIs, uriShared)
+}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/MonkeyBuisness/celli/notebook/merge"
+	"github.com/MonkeyBuisness/celli/notebook/types"
+	"github.com/sirupsen/logrus"
+)
+
+// mergeFormat represents the format of the merged files.
+type mergeFormat int
+
+const (
+	mergeFormatJSON mergeFormat = iota
+	mergeFormatPrettyJSON
+	// mergeFormatTemplate is the format of the files stored by the celli git filter.
+	mergeFormatTemplate
+)
+
+// MergeNotebooks merges the changes of ours and theirs notebook files made since the base one
+// and writes the result to ours file, so it can be used as the git merge driver (%O %A %B %P).
+// The result is written to the output instead if its path is provided.
+//
+// The files can be the templates stored by the celli git filter, the result is written in the format of ours file.
+// The name is the path of the merged file used in the messages (ours file path if it is empty),
+// the URIs of the templates are resolved relative to it.
+// The error is returned only if there are conflicts (or the files could not be read).
+func MergeNotebooks(basePath, oursPath, theirsPath, name string, out OutputOptions) error {
+	if name == "" {
+		name = oursPath
+	}
+
+	base, _, err := readMergeNotebook(basePath, name)
+	if err != nil {
+		return err
+	}
+
+	ours, format, err := readMergeNotebook(oursPath, name)
+	if err != nil {
+		return err
+	}
+
+	theirs, _, err := readMergeNotebook(theirsPath, name)
+	if err != nil {
+		return err
+	}
+
+	merged, conflicts := merge.Notebooks(base, ours, theirs)
+
+	// ours file is the result of the merge driver, so it is always overwritten.
+	if out.Path == "" {
+		out.Path, out.Force = oursPath, true
+	}
+
+	if err := writeOutput(out, func(w io.Writer) error {
+		return writeMergedNotebook(w, merged, format)
+	}); err != nil {
+		return fmt.Errorf("could not write merged notebook: %v", err)
+	}
+
+	for _, conflict := range conflicts {
+		logrus.Warnf("%s: %s", name, conflict)
+	}
+
+	if len(conflicts) != 0 {
+		return fmt.Errorf("%d conflict(s) found", len(conflicts))
+	}
+
+	return nil
+}
+
+// readMergeNotebook reads notebook (or template) file and returns its format.
+//
+// Empty file (e.g. the base of the files added by the both sides) is read as the empty notebook.
+// The files that are not JSON objects are parsed as the templates with the source name.
+func readMergeNotebook(path, sourceName string) (*types.NotebookData, mergeFormat, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, mergeFormatJSON, fmt.Errorf("could not read notebook file: %v", err)
+	}
+
+	var notebook types.NotebookData
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return &notebook, mergeFormatJSON, nil
+	}
+
+	if data[0] != '{' {
+		templateNotebook, err := serializeTemplateSource(bytes.NewReader(data), sourceName)
+		if err != nil {
+			return nil, mergeFormatTemplate, fmt.Errorf("%s: %v", path, err)
+		}
+
+		return templateNotebook, mergeFormatTemplate, nil
+	}
+
+	if err := json.Unmarshal(data, &notebook); err != nil {
+		return nil, mergeFormatJSON, fmt.Errorf("%s: could not parse notebook file: %v", path, err)
+	}
+
+	if bytes.ContainsRune(data, '\n') {
+		return &notebook, mergeFormatPrettyJSON, nil
+	}
+
+	return &notebook, mergeFormatJSON, nil
+}
+
+func writeMergedNotebook(w io.Writer, notebook *types.NotebookData, format mergeFormat) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if format == mergeFormatPrettyJSON {
+		enc.SetIndent("", "\t")
+	}
+	if err := enc.Encode(notebook); err != nil {
+		return err
+	}
+
+	if format != mergeFormatTemplate {
+		_, err := w.Write(buf.Bytes())
+		return err
+	}
+
+	// the template is written the same way the git filter cleans the notebook.
+	return gitFilterClean(&buf, w)
+}
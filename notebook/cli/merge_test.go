@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MonkeyBuisness/celli/notebook/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeNotebooks_Templates(t *testing.T) {
+	clean := func(notebook string) string {
+		var template bytes.Buffer
+		require.NoError(t, gitFilterClean(strings.NewReader(notebook), &template))
+
+		return template.String()
+	}
+	notebook := func(title, code string) string {
+		return `{"cells": [
+			{"languageId": "markdown", "content": "` + title + `", "kind": 1},
+			{"languageId": "java", "content": "` + code + `", "kind": 2}
+		]}`
+	}
+
+	root := testutil.WriteFiles(t, map[string]string{
+		"base.md":   clean(notebook("# Loops", "class Main {}")),
+		"ours.md":   clean(notebook("# Loops and arrays", "class Main {}")),
+		"theirs.md": clean(notebook("# Loops", "class Main { int x; }")),
+	})
+	oursPath := filepath.Join(root, "ours.md")
+
+	require.NoError(t, MergeNotebooks(filepath.Join(root, "base.md"), oursPath, filepath.Join(root, "theirs.md"),
+		"book.javabook", OutputOptions{}))
+
+	data, err := os.ReadFile(oursPath)
+	require.NoError(t, err)
+	require.Equal(t, clean(notebook("# Loops and arrays", "class Main { int x; }")), string(data))
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/MonkeyBuisness/celli/notebook/types"
+	"github.com/MonkeyBuisness/celli/notebook/utils"
+)
+
+// StdStream is the path that means stdin for the input files and stdout for the output files.
+const StdStream = "-"
+
+const backupFileExt = ".bak"
+
+// OutputOptions represents output file configuration model.
+type OutputOptions struct {
+	// Path is the output file path, empty or "-" means stdout.
+	Path string
+	// Force allows to overwrite the existing file.
+	Force bool
+	// Backup keeps a copy of the overwritten file with the .bak extension.
+	Backup bool
+}
+
+// writeOutput writes the data produced by the write function to the output.
+//
+// The data is buffered, so the output file is not touched if the write function fails,
+// and then written atomically.
+func writeOutput(out OutputOptions, write func(w io.Writer) error) error {
+	if isStdStream(out.Path) {
+		return write(os.Stdout)
+	}
+
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		return err
+	}
+
+	return writeOutputFile(out, buf.Bytes())
+}
+
+func writeOutputFile(out OutputOptions, data []byte) error {
+	if err := prepareOutputFile(out); err != nil {
+		return err
+	}
+
+	path := filepath.Clean(out.Path)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("could not create output directory: %v", err)
+	}
+
+	if err := utils.WriteFileAtomic(path, data, types.DefaultFileMode); err != nil {
+		return fmt.Errorf("could not write output file: %v", err)
+	}
+
+	return nil
+}
+
+// prepareOutputFile checks whether the existing output file can be overwritten and backs it up if required.
+func prepareOutputFile(out OutputOptions) error {
+	path := filepath.Clean(out.Path)
+
+	info, err := os.Stat(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("could not resolve output file path: %v", err)
+	case info.IsDir():
+		return fmt.Errorf("%s is a directory", out.Path)
+	case !out.Force:
+		return fmt.Errorf("%s already exists, use --force to overwrite it", out.Path)
+	case out.Backup:
+		if err := backupFile(path); err != nil {
+			return fmt.Errorf("could not back up %s: %v", out.Path, err)
+		}
+	}
+
+	return nil
+}
+
+// backupFile copies the file to the backup file with the same mode.
+func backupFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	backupPath := path + backupFileExt
+	if err := utils.WriteFileAtomic(backupPath, data, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	// the existing backup file keeps its own mode on write.
+	return os.Chmod(backupPath, info.Mode().Perm())
+}
+
+// openInput opens the input file or stdin if the path is "-".
+func openInput(path string) (io.ReadCloser, error) {
+	if path == StdStream {
+		return io.NopCloser(os.Stdin), nil
+	}
+
+	return os.OpenFile(filepath.Clean(path), os.O_RDONLY, types.DefaultFileMode)
+}
+
+func isStdStream(path string) bool {
+	return path == "" || path == StdStream
+}
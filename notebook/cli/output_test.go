@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_writeOutput(t *testing.T) {
+	writeString := func(data string) func(w io.Writer) error {
+		return func(w io.Writer) error {
+			_, err := io.WriteString(w, data)
+			return err
+		}
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "book.javabook")
+
+	t.Run("new file", func(t *testing.T) {
+		require.NoError(t, writeOutput(OutputOptions{Path: path}, writeString("long previous content")))
+	})
+
+	t.Run("existing file without force", func(t *testing.T) {
+		err := writeOutput(OutputOptions{Path: path}, writeString("new"))
+		require.EqualError(t, err, fmt.Sprintf("%s already exists, use --force to overwrite it", path))
+	})
+
+	t.Run("failed write keeps the file", func(t *testing.T) {
+		err := writeOutput(OutputOptions{Path: path, Force: true}, func(w io.Writer) error {
+			_, _ = io.WriteString(w, "partial")
+			return errors.New("conversion error")
+		})
+		require.EqualError(t, err, "conversion error")
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "long previous content", string(data))
+	})
+
+	t.Run("force with backup", func(t *testing.T) {
+		require.NoError(t, os.Chmod(path, 0o600))
+		require.NoError(t, writeOutput(OutputOptions{Path: path, Force: true, Backup: true}, writeString("new")))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "new", string(data))
+
+		data, err = os.ReadFile(path + backupFileExt)
+		require.NoError(t, err)
+		require.Equal(t, "long previous content", string(data))
+
+		info, err := os.Stat(path + backupFileExt)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	})
+}
+
+func TestCreateTemplate(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, CreateTemplate("javabook", OutputOptions{Path: dir}))
+	_, err := os.Stat(filepath.Join(dir, defaultTemplateFileName))
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "custom.md")
+	require.NoError(t, os.WriteFile(path, make([]byte, 1<<16), 0o600))
+	require.NoError(t, CreateTemplate("javabook", OutputOptions{Path: path, Force: true}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "\x00")
+}
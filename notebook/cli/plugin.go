@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/MonkeyBuisness/celli/notebook/plugin"
+	"github.com/MonkeyBuisness/celli/notebook/types"
+	"github.com/sirupsen/logrus"
+)
+
+// TrustPluginsEnvVar is the environment variable that allows the plugins of the project to run.
+const TrustPluginsEnvVar = "CELLI_TRUST_PLUGINS"
+
+var untrustedPluginsWarning sync.Once
+
+// commentSerializers returns enabled built-in comment serializers followed by the configured plugins.
+func commentSerializers() ([]types.SerializableComment, error) {
+	builtinSerializers := make(map[string]types.SerializableComment)
+	for _, s := range defaultCommentSerializers() {
+		builtinSerializers[s.Key()] = s
+	}
+
+	serializers := defaultCommentSerializers()
+	if len(currentConfig.Serializers) != 0 {
+		serializers = make([]types.SerializableComment, 0, len(currentConfig.Serializers))
+		for _, key := range currentConfig.Serializers {
+			s, ok := builtinSerializers[key]
+			if !ok {
+				keys := make([]string, 0, len(builtinSerializers))
+				for key := range builtinSerializers {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+
+				return nil, fmt.Errorf("unknown serializer %q, supported serializers: %s",
+					key, strings.Join(keys, ", "))
+			}
+			serializers = append(serializers, s)
+		}
+	}
+
+	plugins, err := plugin.Load(trustedPlugins())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range plugins {
+		if _, ok := builtinSerializers[p.Key()]; ok {
+			return nil, fmt.Errorf("plugin %s: key is reserved by the built-in comment", p.Key())
+		}
+		serializers = append(serializers, p)
+	}
+
+	return serializers, nil
+}
+
+// trustedPlugins returns the configured plugins allowed to run.
+//
+// Plugins declared by the project run executables of the repository, so they are skipped
+// (with a warning) until the project is trusted explicitly.
+func trustedPlugins() map[string]plugin.Config {
+	if currentConfig.TrustPlugins {
+		return currentConfig.Plugins
+	}
+
+	configs := make(map[string]plugin.Config, len(currentConfig.Plugins))
+	var untrusted []string
+	for key, cfg := range currentConfig.Plugins {
+		if cfg.Project {
+			untrusted = append(untrusted, key)
+			continue
+		}
+		configs[key] = cfg
+	}
+
+	if len(untrusted) != 0 {
+		sort.Strings(untrusted)
+		untrustedPluginsWarning.Do(func() {
+			logrus.Warnf("project plugins %s are skipped, pass --trust-plugins (or set %s=1) to run them",
+				strings.Join(untrusted, ", "), TrustPluginsEnvVar)
+		})
+	}
+
+	return configs
+}
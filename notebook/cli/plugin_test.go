@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/MonkeyBuisness/celli/notebook/config"
+	"github.com/MonkeyBuisness/celli/notebook/plugin"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_trustedPlugins(t *testing.T) {
+	cfg := config.Default()
+	cfg.Plugins = map[string]plugin.Config{
+		"lint":  {Command: "celli-lint"},
+		"chart": {Command: "/repo/tools/chart", Project: true},
+	}
+	Configure(cfg)
+	defer Configure(config.Default())
+
+	require.Equal(t, map[string]plugin.Config{
+		"lint": {Command: "celli-lint"},
+	}, trustedPlugins())
+
+	cfg.TrustPlugins = true
+	require.Equal(t, cfg.Plugins, trustedPlugins())
+}
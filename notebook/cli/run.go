@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/MonkeyBuisness/celli/notebook/runner"
+	"github.com/MonkeyBuisness/celli/notebook/serializer"
+	"github.com/MonkeyBuisness/celli/notebook/types"
+	"github.com/MonkeyBuisness/celli/notebook/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const templateFileExt = ".md"
+
+// RunNotebook executes code cells of the notebook (or template) file
+// and writes the notebook with the captured outputs.
+//
+// The serializer options are applied to the template files only.
+func RunNotebook(path string, out OutputOptions, pretty bool,
+	serializerOpts []serializer.Option, opt ...runner.Option) error {
+	notebookData, err := readNotebook(path, serializerOpts...)
+	if err != nil {
+		return err
+	}
+
+	r := runner.New(opt...)
+	results, err := r.Run(context.Background(), notebookData)
+	if err != nil {
+		return fmt.Errorf("could not run notebook: %v", err)
+	}
+
+	data, err := json.Marshal(notebookData)
+	if err != nil {
+		return err
+	}
+
+	if err := writeOutput(out, func(w io.Writer) error {
+		return writeJSON(w, data, pretty)
+	}); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, result := range results {
+		switch {
+		case result.TimedOut:
+			logrus.Warnf("code cell %d: timed out", result.CodeCell)
+		case result.ExitCode != 0:
+			logrus.Warnf("code cell %d: exit status %d", result.CodeCell, result.ExitCode)
+		default:
+			continue
+		}
+		failed++
+	}
+
+	if failed != 0 {
+		return fmt.Errorf("%d of %d cell(s) failed", failed, len(results))
+	}
+
+	return nil
+}
+
+// readNotebook reads notebook file or serializes template file (*.md) to the notebook.
+func readNotebook(path string, opt ...serializer.Option) (*types.NotebookData, error) {
+	if strings.EqualFold(filepath.Ext(path), templateFileExt) {
+		return serializeTemplate(path, opt...)
+	}
+
+	file, err := openInput(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open notebook file: %v", err)
+	}
+	defer utils.Close(file)
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not read notebook file: %v", err)
+	}
+
+	var notebookData types.NotebookData
+	if err := json.Unmarshal(data, &notebookData); err != nil {
+		return nil, fmt.Errorf("could not parse notebook file: %v", err)
+	}
+
+	return &notebookData, nil
+}
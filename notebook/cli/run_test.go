@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MonkeyBuisness/celli/notebook/serializer"
+	"github.com/MonkeyBuisness/celli/notebook/types"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_readNotebook_SerializerOptions(t *testing.T) {
+	templatePath := filepath.Join(t.TempDir(), "book.md")
+	require.NoError(t, os.WriteFile(templatePath, []byte("text\n\n```java\nclass Main {}\n```\n"), 0o600))
+
+	notebook, err := readNotebook(templatePath)
+	require.NoError(t, err)
+	require.Len(t, notebook.Cells, 1)
+
+	notebook, err = readNotebook(templatePath, serializer.WithFencedCode())
+	require.NoError(t, err)
+	require.Len(t, notebook.Cells, 2)
+	require.Equal(t, types.NotebookCellKindCode, notebook.Cells[1].Kind)
+}
@@ -4,8 +4,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
-	"path"
 	"path/filepath"
 	"strings"
 
@@ -15,6 +15,7 @@ import (
 	"github.com/MonkeyBuisness/celli/notebook/template"
 	"github.com/MonkeyBuisness/celli/notebook/types"
 	"github.com/MonkeyBuisness/celli/notebook/utils"
+	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -22,48 +23,51 @@ const (
 )
 
 // CreateTemplate creates a new template based on the type.
-func CreateTemplate(bookType, dest string) error {
-	templateData, err := template.NewBookTemplate(types.BookType(strings.ToLower(bookType)))
+//
+// If the output path is a directory, template.md is created in it.
+func CreateTemplate(bookType string, out OutputOptions, opt ...template.Option) error {
+	templateData, err := template.NewBookTemplate(types.BookType(strings.ToLower(bookType)), opt...)
 	if err != nil {
 		return err
 	}
 
-	fileInfo, err := os.Stat(dest)
-	if err != nil {
-		return fmt.Errorf("could not resolve template file path: %v", err)
+	if !isStdStream(out.Path) {
+		if fileInfo, err := os.Stat(out.Path); err == nil && fileInfo.IsDir() {
+			out.Path = filepath.Join(out.Path, defaultTemplateFileName)
+		}
 	}
 
-	if fileInfo.IsDir() {
-		dest = path.Join(dest, defaultTemplateFileName)
-	}
+	return writeOutput(out, func(w io.Writer) error {
+		if _, err := w.Write(templateData); err != nil {
+			return fmt.Errorf("could not write data to the template file: %v", err)
+		}
 
-	file, err := os.OpenFile(filepath.Clean(dest), os.O_CREATE|os.O_WRONLY, types.DefaultFileMode)
-	if err != nil {
-		return fmt.Errorf("could not open template file: %v", err)
-	}
-	defer utils.Close(file)
+		return nil
+	})
+}
 
-	if _, err := file.Write(templateData); err != nil {
-		return fmt.Errorf("could not write data to the template file: %v", err)
-	}
+// ConvertToTemplate converts notebook file to the template implementation.
+func ConvertToTemplate(notebookPath string, out OutputOptions, opt ...converter.Option) error {
+	checkNotebookType(notebookPath)
 
-	return nil
+	return writeOutput(out, func(w io.Writer) error {
+		return convertToTemplate(notebookPath, w, opt...)
+	})
 }
 
-// ConvertToTemplate converts notebook file to the template implementation.
-func ConvertToTemplate(notebookPath string) error {
-	file, err := os.OpenFile(filepath.Clean(notebookPath), os.O_RDONLY, types.DefaultFileMode)
+func convertToTemplate(notebookPath string, w io.Writer, opt ...converter.Option) error {
+	file, err := openInput(notebookPath)
 	if err != nil {
 		return fmt.Errorf("could not open notebook file: %v", err)
 	}
 	defer utils.Close(file)
 
-	data, err := converter.Proceed(file)
+	data, err := converter.Proceed(file, opt...)
 	if err != nil {
 		return fmt.Errorf("could not convert notebook data: %v", err)
 	}
 
-	if _, err := os.Stdout.Write(data); err != nil {
+	if _, err := w.Write(data); err != nil {
 		return err
 	}
 
@@ -71,25 +75,82 @@ func ConvertToTemplate(notebookPath string) error {
 }
 
 // ConvertToNotebook converts template file to the notebook implementation.
-func ConvertToNotebook(templatePath string, pretty bool) error {
-	file, err := os.OpenFile(filepath.Clean(templatePath), os.O_RDONLY, types.DefaultFileMode)
+func ConvertToNotebook(templatePath string, out OutputOptions, pretty bool, opt ...serializer.Option) error {
+	return writeOutput(out, func(w io.Writer) error {
+		return convertToNotebook(templatePath, w, pretty, opt...)
+	})
+}
+
+func convertToNotebook(templatePath string, w io.Writer, pretty bool, opt ...serializer.Option) error {
+	notebookData, err := serializeTemplate(templatePath, opt...)
 	if err != nil {
-		return fmt.Errorf("could not open notebook file: %v", err)
+		return err
+	}
+
+	data, err := json.Marshal(notebookData)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, data, pretty)
+}
+
+func serializeTemplate(templatePath string, opt ...serializer.Option) (*types.NotebookData, error) {
+	file, err := openInput(templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not open notebook file: %v", err)
 	}
 	defer utils.Close(file)
 
+	return serializeTemplateSource(file, templatePath, opt...)
+}
+
+// serializeTemplateSource serializes the template read from the source,
+// its includes and lockfile are resolved relative to the template path.
+func serializeTemplateSource(source io.Reader, templatePath string,
+	opt ...serializer.Option) (*types.NotebookData, error) {
+	uriResolver, err := templateResolver(templatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	serializers, err := commentSerializers()
+	if err != nil {
+		return nil, err
+	}
+
 	s := serializer.New()
-	notebookData, err := s.SerializeNotebook(file,
-		serializer.WithCommentSerializer(
-			comments.NewCodeCommentSerializer(),
-			comments.NewBrCommentSerializer(),
-			comments.NewNotebookCommentSerializer(),
-			comments.NewAuthorCommentSerializer(),
-			comments.NewYCodeCommentSerializer(),
-		),
-	)
+	opts := append([]serializer.Option{
+		serializer.WithSourceName(templatePath),
+		serializer.WithIncludes(),
+		serializer.WithURIResolver(uriResolver),
+		serializer.WithCommentSerializer(serializers...),
+	}, opt...)
+	notebookData, err := s.SerializeNotebook(source, opts...)
 	if err != nil {
-		return fmt.Errorf("could not serialize notebook data: %v", err)
+		return nil, fmt.Errorf("could not serialize notebook data: %v", err)
+	}
+
+	return notebookData, nil
+}
+
+// ConvertIPYNBToNotebook converts Jupyter notebook file to the notebook implementation.
+func ConvertIPYNBToNotebook(ipynbPath string, out OutputOptions, pretty bool) error {
+	return writeOutput(out, func(w io.Writer) error {
+		return convertIPYNBToNotebook(ipynbPath, w, pretty)
+	})
+}
+
+func convertIPYNBToNotebook(ipynbPath string, w io.Writer, pretty bool) error {
+	file, err := openInput(ipynbPath)
+	if err != nil {
+		return fmt.Errorf("could not open ipynb file: %v", err)
+	}
+	defer utils.Close(file)
+
+	notebookData, err := converter.ProceedIPYNB(file)
+	if err != nil {
+		return fmt.Errorf("could not convert ipynb data: %v", err)
 	}
 
 	data, err := json.Marshal(notebookData)
@@ -97,6 +158,34 @@ func ConvertToNotebook(templatePath string, pretty bool) error {
 		return err
 	}
 
+	return writeJSON(w, data, pretty)
+}
+
+// ConvertNotebookToIPYNB converts notebook file to the Jupyter notebook implementation.
+func ConvertNotebookToIPYNB(notebookPath string, out OutputOptions, pretty bool) error {
+	checkNotebookType(notebookPath)
+
+	return writeOutput(out, func(w io.Writer) error {
+		return convertNotebookToIPYNB(notebookPath, w, pretty)
+	})
+}
+
+func convertNotebookToIPYNB(notebookPath string, w io.Writer, pretty bool) error {
+	file, err := openInput(notebookPath)
+	if err != nil {
+		return fmt.Errorf("could not open notebook file: %v", err)
+	}
+	defer utils.Close(file)
+
+	data, err := converter.CreateIPYNB(file)
+	if err != nil {
+		return fmt.Errorf("could not convert notebook data: %v", err)
+	}
+
+	return writeJSON(w, data, pretty)
+}
+
+func writeJSON(w io.Writer, data []byte, pretty bool) error {
 	if pretty {
 		var buf bytes.Buffer
 		if err := json.Indent(&buf, data, "", "\t"); err != nil {
@@ -105,9 +194,40 @@ func ConvertToNotebook(templatePath string, pretty bool) error {
 		data = buf.Bytes()
 	}
 
-	if _, err := os.Stdout.Write(data); err != nil {
+	if _, err := w.Write(data); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// checkNotebookType warns if the notebook file extension does not belong to any of the known book types.
+func checkNotebookType(notebookPath string) {
+	if notebookPath == StdStream {
+		return
+	}
+
+	ext := filepath.Ext(notebookPath)
+	if _, ok, err := template.BookTypeByExtension(ext); err != nil || ok {
+		return
+	}
+
+	bookTypes, err := template.SupportedBookTypes()
+	if err != nil {
+		return
+	}
+
+	logrus.Warnf("%s: unknown notebook file extension %q, supported book types: %s",
+		notebookPath, ext, strings.Join(bookTypes, ", "))
+}
+
+func defaultCommentSerializers() []types.SerializableComment {
+	return []types.SerializableComment{
+		comments.NewCodeCommentSerializer(),
+		comments.NewBrCommentSerializer(),
+		comments.NewNotebookCommentSerializer(),
+		comments.NewAuthorCommentSerializer(),
+		comments.NewYCodeCommentSerializer(),
+		comments.NewOutputCommentSerializer(),
+	}
+}
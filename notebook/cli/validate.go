@@ -0,0 +1,230 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+
+	e "github.com/MonkeyBuisness/celli/notebook/errors"
+	"github.com/MonkeyBuisness/celli/notebook/serializer"
+	"github.com/MonkeyBuisness/celli/notebook/utils"
+)
+
+// Validation report format.
+const (
+	ReportFormatText  = "text"
+	ReportFormatJSON  = "json"
+	ReportFormatSARIF = "sarif"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifToolURI = "https://github.com/MonkeyBuisness/celli"
+	sarifToolID  = "celli"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules,omitempty"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+// SupportedReportFormats returns a slice of supported validation report formats.
+func SupportedReportFormats() []string {
+	return []string{
+		ReportFormatText,
+		ReportFormatJSON,
+		ReportFormatSARIF,
+	}
+}
+
+// ValidateTemplate validates template file and writes all the problems found in the provided format to the output.
+//
+// The error is returned only if there are problems of the error severity.
+func ValidateTemplate(templatePath, format string, out OutputOptions, opt ...serializer.Option) error {
+	file, err := openInput(templatePath)
+	if err != nil {
+		return fmt.Errorf("could not open template file: %v", err)
+	}
+	defer utils.Close(file)
+
+	uriResolver, err := templateResolver(templatePath)
+	if err != nil {
+		return err
+	}
+
+	serializers, err := commentSerializers()
+	if err != nil {
+		return err
+	}
+
+	s := serializer.New()
+	opts := append([]serializer.Option{
+		serializer.WithSourceName(templatePath),
+		serializer.WithIncludes(),
+		serializer.WithURIResolver(uriResolver),
+		serializer.WithCommentSerializer(serializers...),
+	}, opt...)
+	diagnostics, err := s.Validate(file, opts...)
+	if err != nil {
+		return fmt.Errorf("could not validate template: %v", err)
+	}
+
+	if err := writeOutput(out, func(w io.Writer) error {
+		return writeReport(w, diagnostics, format)
+	}); err != nil {
+		return err
+	}
+
+	// warnings (e.g. empty code cells) are reported but do not fail the validation.
+	errorsCount := 0
+	for i := range diagnostics {
+		if diagnostics[i].Severity == serializer.SeverityError {
+			errorsCount++
+		}
+	}
+	if errorsCount != 0 {
+		return e.ErrInvalidTemplate.New(fmt.Sprintf("%d error(s) found", errorsCount))
+	}
+
+	return nil
+}
+
+func writeReport(w io.Writer, diagnostics []serializer.Diagnostic, format string) error {
+	switch format {
+	case ReportFormatText, "":
+		for i := range diagnostics {
+			if _, err := fmt.Fprintf(w, "%s: %s\n", diagnostics[i].Severity, diagnostics[i]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case ReportFormatJSON:
+		if diagnostics == nil {
+			diagnostics = []serializer.Diagnostic{}
+		}
+
+		return writeIndentedJSON(w, diagnostics)
+	case ReportFormatSARIF:
+		return writeIndentedJSON(w, newSARIFLog(diagnostics))
+	}
+
+	return fmt.Errorf("unsupported report format %q", format)
+}
+
+func writeIndentedJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "\t")
+
+	return enc.Encode(v)
+}
+
+func newSARIFLog(diagnostics []serializer.Diagnostic) sarifLog {
+	results := make([]sarifResult, len(diagnostics))
+	ruleIDs := make(map[string]struct{})
+	for i := range diagnostics {
+		d := &diagnostics[i]
+
+		ruleIDs[d.Code] = struct{}{}
+		results[i] = sarifResult{
+			RuleID: d.Code,
+			Level:  string(d.Severity),
+			Message: sarifMessage{
+				Text: d.Message,
+			},
+			Locations: []sarifLocation{
+				{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{
+							URI: filepath.ToSlash(d.Position.File),
+						},
+						Region: sarifRegion{
+							StartLine:   d.Position.Line,
+							StartColumn: d.Position.Column,
+						},
+					},
+				},
+			},
+		}
+	}
+
+	rules := make([]sarifRule, 0, len(ruleIDs))
+	for id := range ruleIDs {
+		rules = append(rules, sarifRule{
+			ID: id,
+		})
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].ID < rules[j].ID
+	})
+
+	return sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{
+			{
+				Tool: sarifTool{
+					Driver: sarifDriver{
+						Name:           sarifToolID,
+						InformationURI: sarifToolURI,
+						Rules:          rules,
+					},
+				},
+				Results: results,
+			},
+		},
+	}
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MonkeyBuisness/celli/notebook/serializer"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_writeReport(t *testing.T) {
+	diagnostics := []serializer.Diagnostic{
+		{
+			Severity: serializer.SeverityWarning,
+			Code:     serializer.DiagnosticUnknownKey,
+			Position: serializer.Position{File: "book.md", Line: 2, Column: 6},
+			Message:  "could not serialize comment: unknown key cdoe",
+		},
+	}
+
+	t.Run("unsupported format", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.EqualError(t, writeReport(&buf, diagnostics, "xml"), `unsupported report format "xml"`)
+	})
+	t.Run("text", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, writeReport(&buf, diagnostics, ReportFormatText))
+		require.Equal(t, "warning: book.md:2:6: could not serialize comment: unknown key cdoe\n", buf.String())
+	})
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, writeReport(&buf, nil, ReportFormatJSON))
+		require.Equal(t, "[]\n", buf.String())
+	})
+	t.Run("sarif", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, writeReport(&buf, diagnostics, ReportFormatSARIF))
+
+		var log sarifLog
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+		require.Equal(t, sarifVersion, log.Version)
+		require.Len(t, log.Runs, 1)
+		require.Equal(t, []sarifRule{{ID: serializer.DiagnosticUnknownKey}}, log.Runs[0].Tool.Driver.Rules)
+		require.Len(t, log.Runs[0].Results, 1)
+
+		result := log.Runs[0].Results[0]
+		require.Equal(t, "warning", result.Level)
+		require.Equal(t, "book.md", result.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+		require.Equal(t, sarifRegion{StartLine: 2, StartColumn: 6}, result.Locations[0].PhysicalLocation.Region)
+	})
+}
+
+func Test_ValidateTemplate_SerializerOptions(t *testing.T) {
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "book.md")
+	require.NoError(t, os.WriteFile(templatePath,
+		[]byte("```html\n<!-- code:{ -->\n```\n"), 0o600))
+
+	err := ValidateTemplate(templatePath, ReportFormatText, OutputOptions{Path: filepath.Join(dir, "report.txt")})
+	require.Error(t, err)
+
+	require.NoError(t, ValidateTemplate(templatePath, ReportFormatText,
+		OutputOptions{Path: filepath.Join(dir, "fenced.txt")}, serializer.WithFencedCode()))
+}
@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/MonkeyBuisness/celli/notebook/resolver"
+	"github.com/MonkeyBuisness/celli/notebook/serializer"
+	"github.com/MonkeyBuisness/celli/notebook/types"
+	"github.com/MonkeyBuisness/celli/notebook/watch"
+	"github.com/sirupsen/logrus"
+)
+
+// dependencies represents set of the local files the template is built from,
+// their states are remembered before they are read.
+type dependencies struct {
+	snapshot *watch.Snapshot
+}
+
+// dependencyResolver represents URI resolver that records local files of the resolved URIs.
+type dependencyResolver struct {
+	base types.URIResolver
+	deps *dependencies
+}
+
+// WatchTemplate converts template file to the notebook file and rebuilds it every time the template,
+// any of its includes or any of the local files referenced from it is changed.
+//
+// The existing output file is overwritten (and backed up) once according to the output options,
+// the rebuilds replace the file written by the watcher. Build errors are logged,
+// watching stops when the context is done.
+func WatchTemplate(ctx context.Context, templatePath string, out OutputOptions, pretty bool,
+	w *watch.Watcher, opt ...serializer.Option) error {
+	if isStdStream(out.Path) {
+		return fmt.Errorf("output file is not provided")
+	}
+
+	if err := prepareOutputFile(out); err != nil {
+		return err
+	}
+
+	for {
+		deps, err := buildTemplate(templatePath, out.Path, pretty, opt...)
+		if err != nil {
+			logrus.Errorf("%s: %v", templatePath, err)
+		} else {
+			logrus.Infof("%s: notebook is written to %s", templatePath, out.Path)
+		}
+
+		// files changed during the build are reported at once.
+		w.WatchSnapshot(deps)
+		changed, err := w.Wait(ctx)
+		if err != nil {
+			return nil
+		}
+
+		for _, path := range changed {
+			logrus.Infof("%s: changed", displayPath(path))
+		}
+	}
+}
+
+// buildTemplate writes the notebook of the template to the output file
+// and returns the snapshot of the files the template depends on (even if the build failed).
+func buildTemplate(templatePath, outputPath string, pretty bool, opt ...serializer.Option) (*watch.Snapshot, error) {
+	deps := newDependencies()
+	deps.add(templatePath)
+	deps.add(defaultLockPath(templatePath))
+
+	uriResolver, err := templateResolver(templatePath)
+	if err != nil {
+		return deps.snapshot, err
+	}
+
+	opts := append(append([]serializer.Option{}, opt...),
+		serializer.WithURIResolver(&dependencyResolver{base: uriResolver, deps: deps}),
+		serializer.WithIncludeObserver(deps.add),
+	)
+
+	var buf bytes.Buffer
+	if err := convertToNotebook(templatePath, &buf, pretty, opts...); err != nil {
+		return deps.snapshot, err
+	}
+
+	if err := writeOutputFile(OutputOptions{Path: outputPath, Force: true}, buf.Bytes()); err != nil {
+		return deps.snapshot, err
+	}
+
+	return deps.snapshot, nil
+}
+
+func newDependencies() *dependencies {
+	return &dependencies{
+		snapshot: watch.NewSnapshot(),
+	}
+}
+
+func (d *dependencies) add(path string) {
+	if absPath, err := filepath.Abs(path); err == nil {
+		path = absPath
+	}
+	d.snapshot.Add(path)
+}
+
+// Resolve records the local file of the URI and resolves it with the base resolver.
+func (r *dependencyResolver) Resolve(uri string) ([]byte, error) {
+	if path, ok := resolver.FilePath(uri); ok {
+		r.deps.add(path)
+	}
+
+	return r.base.Resolve(uri)
+}
+
+func displayPath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+
+	if rel, err := filepath.Rel(wd, path); err == nil {
+		return rel
+	}
+
+	return path
+}
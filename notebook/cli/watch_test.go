@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MonkeyBuisness/celli/notebook/internal/testutil"
+	"github.com/MonkeyBuisness/celli/notebook/watch"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_buildTemplate(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"book.md":       "# Book\n<!-- include:{\"uri\": \"file://chapters/01.md\"} -->",
+		"src/Main.java": "class Main {}",
+	})
+
+	chapterPath := filepath.Join(root, "chapters", "01.md")
+	srcPath := filepath.Join(root, "src", "Main.java")
+	require.NoError(t, os.MkdirAll(filepath.Dir(chapterPath), 0o700))
+	require.NoError(t, os.WriteFile(chapterPath, []byte(fmt.Sprintf(
+		"# Chapter 1\n<!-- code:{\"lang\": \"java\", \"uri\": \"file://%s\"} -->", filepath.ToSlash(srcPath))), 0o600))
+
+	templatePath := filepath.Join(root, "book.md")
+	outputPath := filepath.Join(root, "book.javabook")
+
+	t.Run("all ok", func(t *testing.T) {
+		deps, err := buildTemplate(templatePath, outputPath, false)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{
+			templatePath,
+			defaultLockPath(templatePath),
+			chapterPath,
+			srcPath,
+		}, deps.Paths())
+
+		data, err := os.ReadFile(outputPath)
+		require.NoError(t, err)
+		require.Contains(t, string(data), "class Main {}")
+	})
+
+	t.Run("failed build keeps dependencies", func(t *testing.T) {
+		require.NoError(t, os.Remove(srcPath))
+
+		deps, err := buildTemplate(templatePath, outputPath, false)
+		require.Error(t, err)
+		require.Contains(t, deps.Paths(), srcPath)
+	})
+}
+
+func TestWatchTemplate_ExistingOutput(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"book.md":       "# Book",
+		"book.javabook": "{}",
+	})
+	outputPath := filepath.Join(root, "book.javabook")
+
+	err := WatchTemplate(context.Background(), filepath.Join(root, "book.md"),
+		OutputOptions{Path: outputPath}, false, watch.New())
+	require.EqualError(t, err, fmt.Sprintf("%s already exists, use --force to overwrite it", outputPath))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, WatchTemplate(ctx, filepath.Join(root, "book.md"),
+		OutputOptions{Path: outputPath, Force: true, Backup: true}, false, watch.New()))
+
+	data, err := os.ReadFile(outputPath + backupFileExt)
+	require.NoError(t, err)
+	require.Equal(t, "{}", string(data))
+
+	data, err = os.ReadFile(outputPath)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "# Book")
+}
@@ -0,0 +1,211 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/MonkeyBuisness/celli/notebook/plugin"
+	"github.com/MonkeyBuisness/celli/notebook/resolver"
+	"github.com/MonkeyBuisness/celli/notebook/types"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// FileName is the name of the project configuration file.
+	FileName = ".celli.yaml"
+	// ProjectDirName is the name of the directory with the project plugins and book types.
+	ProjectDirName = ".celli"
+)
+
+// Config represents celli configuration model.
+type Config struct {
+	Pretty       bool                     `yaml:"pretty"`
+	FencedCode   bool                     `yaml:"fenced-code"`
+	FrontMatter  bool                     `yaml:"front-matter"`
+	Strict       bool                     `yaml:"strict"`
+	ReportFormat string                   `yaml:"report-format"`
+	BookType     string                   `yaml:"book-type,omitempty"`
+	Serializers  []string                 `yaml:"serializers,omitempty"`
+	Resolver     ResolverConfig           `yaml:"resolver"`
+	Plugins      map[string]plugin.Config `yaml:"plugins,omitempty"`
+
+	// TrustPlugins allows the plugins declared by the project to run.
+	// It is never read from the project configuration file.
+	TrustPlugins bool `yaml:"-"`
+}
+
+// ResolverConfig represents URI resolver configuration model.
+type ResolverConfig struct {
+	Schemes     []string      `yaml:"schemes"`
+	HTTPTimeout time.Duration `yaml:"http-timeout"`
+	HTTPMaxSize int64         `yaml:"http-max-size"`
+}
+
+// Default returns default configuration.
+func Default() *Config {
+	return &Config{
+		ReportFormat: "text",
+		Resolver: ResolverConfig{
+			Schemes: []string{
+				resolver.SchemeFile,
+				resolver.SchemeHTTP,
+				resolver.SchemeHTTPS,
+				resolver.SchemeData,
+			},
+			HTTPTimeout: resolver.DefaultHTTPTimeout,
+			HTTPMaxSize: resolver.DefaultHTTPMaxSize,
+		},
+	}
+}
+
+// Find walks up from the directory and returns the path of the first configuration file found.
+func Find(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+
+	for {
+		path := filepath.Join(dir, FileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// FindRoot walks up from the directory and returns the first directory with the configuration file
+// or the project directory, so the project files are found from any of its subdirectories.
+// The directory itself is returned if there is no one.
+func FindRoot(dir string) string {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+
+	for d := absDir; ; {
+		if info, err := os.Stat(filepath.Join(d, FileName)); err == nil && !info.IsDir() {
+			return d
+		}
+		if info, err := os.Stat(filepath.Join(d, ProjectDirName)); err == nil && info.IsDir() {
+			return d
+		}
+
+		parent := filepath.Dir(d)
+		if parent == d {
+			return absDir
+		}
+		d = parent
+	}
+}
+
+// Load returns the effective configuration for the directory and the path of the configuration file
+// (or an empty string if there is no one).
+//
+// Plugins of the plugins.json files are merged with the plugins of the configuration file.
+func Load(dir string) (*Config, string, error) {
+	cfg := Default()
+
+	var userPaths []string
+	if path, ok := plugin.UserConfigPath(); ok {
+		userPaths = append(userPaths, path)
+	}
+	plugins, err := plugin.ReadConfig(userPaths...)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not read plugins config: %v", err)
+	}
+
+	// plugins of the project override plugins of the user.
+	projectPlugins, err := plugin.ReadConfig(plugin.ProjectConfigPath(FindRoot(dir)))
+	if err != nil {
+		return nil, "", fmt.Errorf("could not read plugins config: %v", err)
+	}
+	for key, cfg := range projectPlugins {
+		cfg.Project = true
+		plugins[key] = cfg
+	}
+	cfg.Plugins = plugins
+
+	path, ok := Find(dir)
+	if !ok {
+		return cfg, "", nil
+	}
+
+	if err := cfg.read(path); err != nil {
+		return nil, "", err
+	}
+
+	return cfg, path, nil
+}
+
+// read reads the configuration file over the current configuration.
+func (c *Config) read(path string) error {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return fmt.Errorf("could not read config: %v", err)
+	}
+
+	plugins := c.Plugins
+	c.Plugins = nil
+	if err := yaml.UnmarshalStrict(data, c); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	// commands of the plugins are relative to the configuration file.
+	if plugins == nil {
+		plugins = make(map[string]plugin.Config, len(c.Plugins))
+	}
+	for key, cfg := range c.Plugins {
+		cfg.Command = plugin.ResolveCommand(cfg.Command, filepath.Dir(path))
+		cfg.Project = true
+		plugins[key] = cfg
+	}
+	c.Plugins = plugins
+
+	if c.BookType != "" {
+		c.BookType = strings.ToLower(c.BookType)
+	}
+
+	return nil
+}
+
+// NewResolver returns URI resolver with the configured schemes.
+func (c *Config) NewResolver() (types.URIResolver, error) {
+	httpResolver := resolver.NewHTTPResolver(c.Resolver.HTTPTimeout, c.Resolver.HTTPMaxSize)
+
+	registry := resolver.NewRegistry()
+	for _, scheme := range c.Resolver.Schemes {
+		switch strings.ToLower(scheme) {
+		case resolver.SchemeFile:
+			registry.Register(resolver.SchemeFile, resolver.NewFileResolver(""))
+		case resolver.SchemeHTTP:
+			registry.Register(resolver.SchemeHTTP, httpResolver)
+		case resolver.SchemeHTTPS:
+			registry.Register(resolver.SchemeHTTPS, httpResolver)
+		case resolver.SchemeData:
+			registry.Register(resolver.SchemeData, resolver.NewDataResolver())
+		default:
+			return nil, fmt.Errorf("unsupported resolver scheme %q", scheme)
+		}
+	}
+
+	return registry, nil
+}
+
+// Marshal returns YAML representation of the configuration.
+func (c *Config) Marshal() ([]byte, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal config: %v", err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MonkeyBuisness/celli/notebook/plugin"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Load(t *testing.T) {
+	// isolate from the plugins of the user.
+	configHome := t.TempDir()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+
+	rootDir := t.TempDir()
+	workDir := filepath.Join(rootDir, "chapters", "loops")
+	require.NoError(t, os.MkdirAll(workDir, 0o700))
+
+	t.Run("defaults", func(t *testing.T) {
+		cfg, path, err := Load(workDir)
+		require.NoError(t, err)
+		require.Empty(t, path)
+		require.Equal(t, Default().Resolver, cfg.Resolver)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(rootDir, FileName), []byte(`
+pretty: true
+book-type: KotlinBook
+serializers: [code, br]
+resolver:
+  schemes: [file, https]
+  http-timeout: 5s
+plugins:
+  mermaid:
+    command: ./tools/mermaid
+    args: [--svg]
+  sql:
+    command: celli-sql
+`), 0o600))
+
+		cfg, path, err := Load(workDir)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(rootDir, FileName), path)
+		require.True(t, cfg.Pretty)
+		require.Equal(t, "text", cfg.ReportFormat)
+		require.Equal(t, "kotlinbook", cfg.BookType)
+		require.Equal(t, []string{"code", "br"}, cfg.Serializers)
+		require.Equal(t, ResolverConfig{
+			Schemes:     []string{"file", "https"},
+			HTTPTimeout: 5 * time.Second,
+			HTTPMaxSize: Default().Resolver.HTTPMaxSize,
+		}, cfg.Resolver)
+		require.Equal(t, map[string]plugin.Config{
+			"mermaid": {Command: filepath.Join(rootDir, "tools", "mermaid"), Args: []string{"--svg"}, Project: true},
+			"sql":     {Command: "celli-sql", Project: true},
+		}, cfg.Plugins)
+
+		uriResolver, err := cfg.NewResolver()
+		require.NoError(t, err)
+		_, err = uriResolver.Resolve("data:,hello")
+		require.EqualError(t, err, `unsupported URI scheme "data"`)
+	})
+	t.Run("project plugins", func(t *testing.T) {
+		userPath := filepath.Join(configHome, "celli", "plugins.json")
+		require.NoError(t, os.MkdirAll(filepath.Dir(userPath), 0o700))
+		require.NoError(t, os.WriteFile(userPath, []byte(`{"lint": {"command": "./bin/lint"}}`), 0o600))
+
+		projectPath := plugin.ProjectConfigPath(workDir)
+		require.NoError(t, os.MkdirAll(filepath.Dir(projectPath), 0o700))
+		require.NoError(t, os.WriteFile(projectPath, []byte(`{"chart": {"command": "./bin/chart"}}`), 0o600))
+
+		cfg, _, err := Load(workDir)
+		require.NoError(t, err)
+		require.Equal(t, plugin.Config{Command: filepath.Join(configHome, "celli", "bin", "lint")}, cfg.Plugins["lint"])
+		require.Equal(t, plugin.Config{
+			Command: filepath.Join(workDir, ".celli", "bin", "chart"),
+			Project: true,
+		}, cfg.Plugins["chart"])
+		require.False(t, cfg.TrustPlugins)
+	})
+	t.Run("project plugins from subdirectory", func(t *testing.T) {
+		subDir := filepath.Join(workDir, "src", "main")
+		require.NoError(t, os.MkdirAll(subDir, 0o700))
+
+		cfg, _, err := Load(subDir)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(workDir, ".celli", "bin", "chart"), cfg.Plugins["chart"].Command)
+		require.Equal(t, workDir, FindRoot(subDir))
+		require.Equal(t, rootDir, FindRoot(filepath.Join(rootDir, "chapters")))
+	})
+	t.Run("unknown key", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, FileName), []byte("prety: true\n"), 0o600))
+
+		_, _, err := Load(workDir)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "field prety not found")
+	})
+}
+
+func TestConfig_NewResolver(t *testing.T) {
+	cfg := Default()
+	cfg.Resolver.Schemes = []string{"ftp"}
+
+	_, err := cfg.NewResolver()
+	require.EqualError(t, err, `unsupported resolver scheme "ftp"`)
+}
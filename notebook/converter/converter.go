@@ -5,16 +5,37 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
+	"strings"
 
 	e "github.com/MonkeyBuisness/celli/notebook/errors"
 	"github.com/MonkeyBuisness/celli/notebook/serializer/comments"
 	"github.com/MonkeyBuisness/celli/notebook/types"
+	"gopkg.in/yaml.v2"
 )
 
+const frontMatterDelimiter = "---"
+
+// Option represents converter option model.
+type Option func(*Options)
+
+// Options represents converter configuration model.
+type Options struct {
+	frontMatter  bool
+	noFencedCode bool
+}
+
 // Proceed converts notebook to the template data.
 //
-// Not it only supports `br:`, `code:{}` and `notebook:{}` type of serializable comments.
-func Proceed(source io.Reader) ([]byte, error) {
+// Not it only supports `br:`, `code:{}`, `ycode:{}`, `output:{}` and `notebook:{}` type of serializable comments.
+// Code cells are converted to the same form (comment key, uri, fenced block)
+// they were created from if it is recorded in the cell metadata.
+func Proceed(source io.Reader, opt ...Option) ([]byte, error) {
+	var opts Options
+	for _, o := range opt {
+		o(&opts)
+	}
+
 	// read notebook content.
 	var buf bytes.Buffer
 	if _, err := buf.ReadFrom(source); err != nil {
@@ -28,15 +49,40 @@ func Proceed(source io.Reader) ([]byte, error) {
 	}
 
 	// create template based on notebook data.
-	return createTemplateData(&notebook)
+	return createTemplateData(&notebook, &opts)
+}
+
+// WithFrontMatter enables YAML front matter for the notebook metadata
+// instead of the <!-- notebook:{} --> comment.
+func WithFrontMatter() Option {
+	return func(o *Options) {
+		o.frontMatter = true
+	}
 }
 
-func createTemplateData(notebook *types.NotebookData) ([]byte, error) {
+// WithoutFencedCode writes the code cells created from the fenced code blocks as the code comments,
+// so the template is parsed the same way without the fenced code option of the serializer.
+func WithoutFencedCode() Option {
+	return func(o *Options) {
+		o.noFencedCode = true
+	}
+}
+
+func createTemplateData(notebook *types.NotebookData, opts *Options) ([]byte, error) {
 	buf := make([]byte, 0, len(notebook.Cells))
 
 	// convert notebook metadata.
 	if len(notebook.Metadata) != 0 {
-		buf = append(buf, createMetadataComment(notebook.Metadata)...)
+		createMetadata := createMetadataComment
+		if opts.frontMatter {
+			createMetadata = createFrontMatter
+		}
+
+		metaComment, err := createMetadata(notebook.Metadata)
+		if err != nil {
+			return nil, e.ErrCreateTemplateContent.New(err.Error())
+		}
+		buf = append(buf, metaComment...)
 	}
 
 	// convert cells meatadata.
@@ -48,7 +94,7 @@ func createTemplateData(notebook *types.NotebookData) ([]byte, error) {
 			continue
 		}
 
-		codeComment, err := createCodeComment(c)
+		codeComment, err := createCodeComment(c, opts)
 		if err != nil {
 			return nil, e.ErrCreateTemplateContent.New(err.Error())
 		}
@@ -58,19 +104,134 @@ func createTemplateData(notebook *types.NotebookData) ([]byte, error) {
 	return buf, nil
 }
 
-func createMetadataComment(meta map[string]interface{}) []byte {
-	return []byte(fmt.Sprintf("%s\n", comments.NewNotebook(meta)))
+func createMetadataComment(meta map[string]interface{}) ([]byte, error) {
+	metaComment, err := comments.NewNotebook(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf("%s\n", string(metaComment))), nil
+}
+
+func createFrontMatter(meta map[string]interface{}) ([]byte, error) {
+	data, err := yaml.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf("%s\n%s%s\n", frontMatterDelimiter, data, frontMatterDelimiter)), nil
 }
 
+// createMarkupComment creates markup content followed by the br comment.
+//
+// Surrounding whitespace is trimmed the same way the serializer does, so the conversion is stable
+// across round trips.
 func createMarkupComment(cell *types.NotebookCellData) []byte {
-	return []byte(fmt.Sprintf("%s\n\n%s\n\n", cell.Content, comments.NewBr()))
+	return []byte(fmt.Sprintf("%s\n\n%s\n\n", strings.TrimSpace(cell.Content), comments.NewBr()))
 }
 
-func createCodeComment(cell *types.NotebookCellData) ([]byte, error) {
-	codeComment, err := comments.NewCode(cell)
+// createCodeComment creates code comment in the same form the cell was originally created from.
+func createCodeComment(cell *types.NotebookCellData, opts *Options) ([]byte, error) {
+	src, _ := cell.Source()
+
+	var (
+		codeComment []byte
+		err         error
+	)
+	switch src.Style {
+	case types.CellSourceStyleYAML:
+		codeComment, err = comments.NewYCode(cell, src)
+	case types.CellSourceStyleFence:
+		var ok bool
+		if !opts.noFencedCode {
+			codeComment, ok = createFencedCode(cell)
+		}
+		if !ok {
+			// the metadata that does not fit the fence info is kept by the code comment.
+			codeComment, err = comments.NewCode(cell, src)
+		}
+	default:
+		codeComment, err = comments.NewCode(cell, src)
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	// outputs follow the code cell they belong to.
+	for i := range cell.Outputs {
+		outputComment, err := comments.NewOutput(&cell.Outputs[i])
+		if err != nil {
+			return nil, err
+		}
+		codeComment = append(append(codeComment, '\n'), outputComment...)
+	}
+
 	return []byte(fmt.Sprintf("\n\n%s\n\n", string(codeComment))), nil
 }
+
+// createFencedCode creates fenced code block or returns false if the cell metadata
+// can not be written to the fence info string.
+func createFencedCode(cell *types.NotebookCellData) ([]byte, bool) {
+	fence := "```"
+	for strings.Contains(cell.Content, fence) {
+		fence += "`"
+	}
+
+	info := cell.LanguageID
+	if meta := cell.UserMetadata(); len(meta) != 0 {
+		keys := make([]string, 0, len(meta))
+		for key := range meta {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		pairs := make([]string, len(keys))
+		for i, key := range keys {
+			value, ok := fenceMetaValue(meta[key])
+			if !ok || !isFenceMetaText(key) || key != strings.TrimSpace(key) {
+				return nil, false
+			}
+			pairs[i] = fmt.Sprintf("%s=%s", key, value)
+		}
+		info = fmt.Sprintf("%s {meta=%q}", info, strings.Join(pairs, ", "))
+	}
+
+	return []byte(fmt.Sprintf("%s%s\n%s\n%s", fence, info, strings.TrimSuffix(cell.Content, "\n"), fence)), true
+}
+
+// fenceMetaValue returns the fence meta representation of the flat scalar value.
+func fenceMetaValue(value interface{}) (string, bool) {
+	var text string
+	switch v := value.(type) {
+	case string:
+		// strings looking like a boolean or a number are parsed back as typed values.
+		var parsed interface{}
+		if json.Unmarshal([]byte(v), &parsed) == nil {
+			switch parsed.(type) {
+			case bool, float64:
+				return "", false
+			}
+		}
+		text = v
+	case bool, float64, int:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", false
+		}
+		text = string(data)
+	default:
+		return "", false
+	}
+
+	// surrounding whitespace is trimmed by the fence info parser.
+	if !isFenceMetaText(text) || text != strings.TrimSpace(text) {
+		return "", false
+	}
+
+	return text, true
+}
+
+// isFenceMetaText reports whether the text can be written to the fence meta attribute as is.
+func isFenceMetaText(text string) bool {
+	return !strings.ContainsAny(text, ",=\"\\{}\n`")
+}
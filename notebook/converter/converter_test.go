@@ -0,0 +1,245 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/MonkeyBuisness/celli/notebook/lock"
+	"github.com/MonkeyBuisness/celli/notebook/serializer"
+	"github.com/MonkeyBuisness/celli/notebook/serializer/comments"
+	"github.com/MonkeyBuisness/celli/notebook/types"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_createCodeComment(t *testing.T) {
+	newCell := func(src *types.CellSource) *types.NotebookCellData {
+		cell := &types.NotebookCellData{
+			LanguageID: "java",
+			Content:    "class Main {\n\tint x;\n}\n",
+			Kind:       types.NotebookCellKindCode,
+			Metadata: map[string]interface{}{
+				"is-executable": "false",
+			},
+		}
+		if src != nil {
+			cell.SetSource(*src)
+		}
+
+		return cell
+	}
+
+	t.Run("json by default", func(t *testing.T) {
+		data, err := createCodeComment(newCell(nil), &Options{})
+		require.NoError(t, err)
+		require.Equal(t, "\n\n<!-- code:{\n\t\"lang\": \"java\",\n\t\"meta\": {\n\t\t\"is-executable\": \"false\"\n\t},\n"+
+			"\t\"content\": \"class Main {\\n\\tint x;\\n}\\n\"\n} -->\n\n", string(data))
+	})
+	t.Run("json with uri", func(t *testing.T) {
+		data, err := createCodeComment(newCell(&types.CellSource{
+			Comment:  "code",
+			URI:      "file://Main.java",
+			Style:    types.CellSourceStyleJSON,
+			Checksum: lock.Hash([]byte("class Main {\n\tint x;\n}\n")),
+		}), &Options{})
+		require.NoError(t, err)
+		require.Equal(t, "\n\n<!-- code:{\n\t\"lang\": \"java\",\n\t\"meta\": {\n\t\t\"is-executable\": \"false\"\n\t},\n"+
+			"\t\"uri\": \"file://Main.java\"\n} -->\n\n", string(data))
+	})
+	t.Run("edited uri content", func(t *testing.T) {
+		for _, style := range []types.CellSourceStyle{types.CellSourceStyleJSON, types.CellSourceStyleYAML} {
+			data, err := createCodeComment(newCell(&types.CellSource{
+				URI:      "file://Main.java",
+				Style:    style,
+				Checksum: lock.Hash([]byte("class Main {}\n")),
+			}), &Options{})
+			require.NoError(t, err)
+			require.NotContains(t, string(data), "file://Main.java")
+			require.Contains(t, string(data), "int x;")
+		}
+	})
+	t.Run("yaml", func(t *testing.T) {
+		data, err := createCodeComment(newCell(&types.CellSource{
+			Comment: "ycode",
+			Style:   types.CellSourceStyleYAML,
+		}), &Options{})
+		require.NoError(t, err)
+		require.Equal(t, "\n\n<!-- ycode:{\n    lang: java\n    code: |4\n        class Main {\n        \tint x;\n        }\n"+
+			"    meta:\n      is-executable: \"false\"\n} -->\n\n", string(data))
+	})
+	t.Run("yaml round trip", func(t *testing.T) {
+		for _, content := range []string{
+			"\n    indented();\nback();",
+			"  first();\n",
+			"while (x-->0) {}\n",
+		} {
+			cell := newCell(&types.CellSource{
+				Comment: "ycode",
+				Style:   types.CellSourceStyleYAML,
+			})
+			cell.Content = content
+
+			data, err := createCodeComment(cell, &Options{})
+			require.NoError(t, err)
+
+			s := serializer.New()
+			notebook, err := s.SerializeNotebook(bytes.NewReader(data),
+				serializer.WithStrict(),
+				serializer.WithCommentSerializer(
+					comments.NewCodeCommentSerializer(),
+					comments.NewYCodeCommentSerializer(),
+				),
+			)
+			require.NoError(t, err, string(data))
+			require.Len(t, notebook.Cells, 1)
+			require.Equal(t, content, notebook.Cells[0].Content)
+		}
+	})
+	t.Run("fence", func(t *testing.T) {
+		cell := newCell(&types.CellSource{
+			Style: types.CellSourceStyleFence,
+		})
+		cell.Metadata["is-executable"] = false
+
+		data, err := createCodeComment(cell, &Options{})
+		require.NoError(t, err)
+		require.Equal(t, "\n\n```java {meta=\"is-executable=false\"}\nclass Main {\n\tint x;\n}\n```\n\n", string(data))
+	})
+	t.Run("fence with scalar meta", func(t *testing.T) {
+		for _, value := range []interface{}{"Main", true, false, 3.0, -1.5, 1e21} {
+			cell := newCell(&types.CellSource{
+				Style: types.CellSourceStyleFence,
+			})
+			cell.Metadata["value"] = value
+			delete(cell.Metadata, "is-executable")
+
+			data, err := createCodeComment(cell, &Options{})
+			require.NoError(t, err)
+			require.True(t, strings.HasPrefix(string(data), "\n\n```java {meta="), string(data))
+
+			s := serializer.New()
+			notebook, err := s.SerializeNotebook(bytes.NewReader(data), serializer.WithFencedCode())
+			require.NoError(t, err)
+			require.Equal(t, value, notebook.Cells[0].Metadata["value"])
+		}
+	})
+	t.Run("fence without fenced code", func(t *testing.T) {
+		data, err := createCodeComment(newCell(&types.CellSource{
+			Style: types.CellSourceStyleFence,
+		}), &Options{noFencedCode: true})
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(string(data), "\n\n<!-- code:{"), string(data))
+	})
+	t.Run("fence with complex meta", func(t *testing.T) {
+		for _, value := range []interface{}{
+			"a, b=c", `say "hi"`, "false", "3", map[string]interface{}{"a": 1.0},
+		} {
+			cell := newCell(&types.CellSource{
+				Style: types.CellSourceStyleFence,
+			})
+			cell.Metadata["value"] = value
+
+			data, err := createCodeComment(cell, &Options{})
+			require.NoError(t, err)
+			require.True(t, strings.HasPrefix(string(data), "\n\n<!-- code:{"), string(data))
+
+			s := serializer.New()
+			notebook, err := s.SerializeNotebook(bytes.NewReader(data),
+				serializer.WithCommentSerializer(comments.NewCodeCommentSerializer()),
+			)
+			require.NoError(t, err)
+			require.Equal(t, value, notebook.Cells[0].Metadata["value"])
+		}
+	})
+}
+
+func Test_Proceed_NotebookMetadata(t *testing.T) {
+	const notebookData = `{
+		"metadata": {
+			"version": "1.0",
+			"revision": 3,
+			"draft": false,
+			"license": null,
+			"tags": ["java", 1, true],
+			"nested": {"a": {"b": [1.5, {"c": "d"}]}}
+		}
+	}`
+
+	data, err := Proceed(strings.NewReader(notebookData))
+	require.NoError(t, err)
+
+	s := serializer.New()
+	notebook, err := s.SerializeNotebook(bytes.NewReader(data),
+		serializer.WithCommentSerializer(comments.NewNotebookCommentSerializer()),
+	)
+	require.NoError(t, err)
+
+	var expected types.NotebookData
+	require.NoError(t, json.Unmarshal([]byte(notebookData), &expected))
+	require.Equal(t, expected.Metadata, notebook.Metadata)
+}
+
+func Test_Proceed_FrontMatter(t *testing.T) {
+	const notebookData = `{
+		"metadata": {
+			"version": "1.0",
+			"revision": 3,
+			"tags": ["java", true],
+			"nested": {"a": {"b": [1.5, {"c": "d"}]}}
+		},
+		"cells": [{"languageId": "markdown", "kind": 1, "content": "# Title"}]
+	}`
+
+	data, err := Proceed(strings.NewReader(notebookData), WithFrontMatter())
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(data), "---\nnested:\n"), string(data))
+
+	s := serializer.New()
+	notebook, err := s.SerializeNotebook(bytes.NewReader(data),
+		serializer.WithFrontMatter(),
+		serializer.WithCommentSerializer(comments.NewBrCommentSerializer()),
+	)
+	require.NoError(t, err)
+	require.Len(t, notebook.Cells, 1)
+	require.Equal(t, "# Title", notebook.Cells[0].Content)
+
+	var expected types.NotebookData
+	require.NoError(t, json.Unmarshal([]byte(notebookData), &expected))
+	expectedMeta, err := json.Marshal(expected.Metadata)
+	require.NoError(t, err)
+	actualMeta, err := json.Marshal(notebook.Metadata)
+	require.NoError(t, err)
+	require.JSONEq(t, string(expectedMeta), string(actualMeta))
+}
+
+func Test_Proceed_Outputs(t *testing.T) {
+	const notebookData = `{
+		"cells": [{
+			"languageId": "java",
+			"kind": 2,
+			"content": "System.out.println(1);",
+			"outputs": [
+				{"items": [{"mime": "text/plain", "data": "1\n"}]},
+				{"items": [{"mime": "text/html", "data": "<b>1</b>"}], "metadata": {"executionOrder": 1}}
+			]
+		}]
+	}`
+
+	data, err := Proceed(strings.NewReader(notebookData))
+	require.NoError(t, err)
+
+	s := serializer.New()
+	notebook, err := s.SerializeNotebook(bytes.NewReader(data),
+		serializer.WithCommentSerializer(
+			comments.NewCodeCommentSerializer(),
+			comments.NewOutputCommentSerializer(),
+		),
+	)
+	require.NoError(t, err)
+
+	var expected types.NotebookData
+	require.NoError(t, json.Unmarshal([]byte(notebookData), &expected))
+	require.Len(t, notebook.Cells, 1)
+	require.Equal(t, expected.Cells[0].Outputs, notebook.Cells[0].Outputs)
+}
@@ -0,0 +1,285 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	e "github.com/MonkeyBuisness/celli/notebook/errors"
+	"github.com/MonkeyBuisness/celli/notebook/types"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ipynbFormat      = 4
+	ipynbFormatMinor = 4
+	// ipynbFormatMinorIDs is the first minor version with the cell ids.
+	ipynbFormatMinorIDs = 5
+
+	ipynbCellMarkdown = "markdown"
+	ipynbCellCode     = "code"
+	ipynbCellRaw      = "raw"
+
+	ipynbMetaKernelSpec   = "kernelspec"
+	ipynbMetaLanguageInfo = "language_info"
+
+	// defaultIPYNBLanguage uses when notebook does not contain kernel language.
+	defaultIPYNBLanguage = "java"
+
+	// RawLanguageID is the language of the markup cells converted from (and to) the raw ipynb cells.
+	RawLanguageID = "raw"
+)
+
+type ipynbNotebook struct {
+	Cells         []ipynbCell            `json:"cells"`
+	Metadata      map[string]interface{} `json:"metadata"`
+	NBFormat      int                    `json:"nbformat"`
+	NBFormatMinor int                    `json:"nbformat_minor"`
+}
+
+type ipynbCell struct {
+	ID             string                 `json:"id,omitempty"`
+	CellType       string                 `json:"cell_type"`
+	Metadata       map[string]interface{} `json:"metadata"`
+	Source         ipynbSource            `json:"source"`
+	Outputs        []json.RawMessage      `json:"outputs,omitempty"`
+	ExecutionCount *int                   `json:"execution_count,omitempty"`
+}
+
+// ipynbSource represents multiline string that can be stored as a string or as a slice of lines.
+type ipynbSource string
+
+// UnmarshalJSON unmarshals source from the string or from the slice of strings.
+func (s *ipynbSource) UnmarshalJSON(data []byte) error {
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err == nil {
+		*s = ipynbSource(strings.Join(lines, ""))
+		return nil
+	}
+
+	var source string
+	if err := json.Unmarshal(data, &source); err != nil {
+		return err
+	}
+	*s = ipynbSource(source)
+
+	return nil
+}
+
+// MarshalJSON marshals source as a slice of lines.
+func (s ipynbSource) MarshalJSON() ([]byte, error) {
+	lines := strings.SplitAfter(string(s), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return json.Marshal(lines)
+}
+
+// ProceedIPYNB converts Jupyter notebook (nbformat v4) to the notebook data.
+//
+// Raw cells become the markup cells of the RawLanguageID language,
+// cell ids are kept in the cell metadata (types.CellIDMetadataKey).
+func ProceedIPYNB(source io.Reader) (*types.NotebookData, error) {
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(source); err != nil {
+		return nil, e.ErrReadNotebookSource.New(err.Error())
+	}
+
+	var ipynb ipynbNotebook
+	if err := json.Unmarshal(buf.Bytes(), &ipynb); err != nil {
+		return nil, e.ErrParseNotebookContent.New(err.Error())
+	}
+
+	if ipynb.NBFormat != ipynbFormat {
+		return nil, e.ErrParseNotebookContent.New(
+			fmt.Sprintf("unsupported nbformat version %d", ipynb.NBFormat))
+	}
+
+	notebook, err := createNotebookData(&ipynb)
+	if err != nil {
+		return nil, e.ErrParseNotebookContent.New(err.Error())
+	}
+
+	return notebook, nil
+}
+
+// CreateIPYNB converts notebook to the Jupyter notebook (nbformat v4) data.
+func CreateIPYNB(source io.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(source); err != nil {
+		return nil, e.ErrReadNotebookSource.New(err.Error())
+	}
+
+	var notebook types.NotebookData
+	if err := json.Unmarshal(buf.Bytes(), &notebook); err != nil {
+		return nil, e.ErrParseNotebookContent.New(err.Error())
+	}
+
+	ipynb, err := createIPYNBData(&notebook)
+	if err != nil {
+		return nil, e.ErrCreateIPYNBContent.New(err.Error())
+	}
+
+	data, err := json.Marshal(ipynb)
+	if err != nil {
+		return nil, e.ErrCreateIPYNBContent.New(err.Error())
+	}
+
+	return data, nil
+}
+
+func createNotebookData(ipynb *ipynbNotebook) (*types.NotebookData, error) {
+	language := ipynbLanguage(ipynb.Metadata)
+
+	notebook := types.NotebookData{
+		Cells:    make([]types.NotebookCellData, 0, len(ipynb.Cells)),
+		Metadata: ipynb.Metadata,
+	}
+	for i := range ipynb.Cells {
+		c := &ipynb.Cells[i]
+
+		cell := types.NotebookCellData{
+			LanguageID: types.MarkdownLanguageID,
+			Content:    string(c.Source),
+			Kind:       types.NotebookCellKindMarkup,
+			Metadata:   c.Metadata,
+		}
+		if c.ID != "" {
+			cell.Metadata = make(map[string]interface{}, len(c.Metadata)+1)
+			for key, value := range c.Metadata {
+				cell.Metadata[key] = value
+			}
+			cell.Metadata[types.CellIDMetadataKey] = c.ID
+		}
+		if len(cell.Metadata) == 0 {
+			cell.Metadata = nil
+		}
+
+		switch c.CellType {
+		case ipynbCellCode:
+			outputs, err := createCellOutputs(i, c.Outputs)
+			if err != nil {
+				return nil, err
+			}
+
+			cell.LanguageID = language
+			cell.Kind = types.NotebookCellKindCode
+			cell.Outputs = outputs
+		case ipynbCellRaw:
+			cell.LanguageID = RawLanguageID
+		case ipynbCellMarkdown:
+		default:
+			logrus.Warnf("cell %d: unknown cell type %q will be converted to the markup cell", i, c.CellType)
+		}
+
+		notebook.Cells = append(notebook.Cells, cell)
+	}
+
+	return &notebook, nil
+}
+
+func createIPYNBData(notebook *types.NotebookData) (*ipynbNotebook, error) {
+	ipynb := ipynbNotebook{
+		Cells:         make([]ipynbCell, 0, len(notebook.Cells)),
+		Metadata:      make(map[string]interface{}, len(notebook.Metadata)),
+		NBFormat:      ipynbFormat,
+		NBFormatMinor: ipynbFormatMinor,
+	}
+	for key, value := range notebook.Metadata {
+		ipynb.Metadata[key] = value
+	}
+
+	var language string
+	for i := range notebook.Cells {
+		c := &notebook.Cells[i]
+
+		cell := ipynbCell{
+			ID:       c.ID(),
+			CellType: ipynbCellMarkdown,
+			Metadata: make(map[string]interface{}, len(c.Metadata)),
+			Source:   ipynbSource(c.Content),
+		}
+		for key, value := range c.Metadata {
+			if key == types.CellIDMetadataKey && cell.ID != "" {
+				continue
+			}
+			cell.Metadata[key] = value
+		}
+		if cell.ID != "" {
+			ipynb.NBFormatMinor = ipynbFormatMinorIDs
+		}
+
+		switch {
+		case c.Kind == types.NotebookCellKindCode:
+			outputs, err := createIPYNBOutputs(c.Outputs)
+			if err != nil {
+				return nil, fmt.Errorf("cell %d: %v", i, err)
+			}
+
+			cell.CellType = ipynbCellCode
+			cell.Outputs = outputs
+			if language == "" {
+				language = c.LanguageID
+			}
+		case c.LanguageID == RawLanguageID:
+			cell.CellType = ipynbCellRaw
+		}
+
+		ipynb.Cells = append(ipynb.Cells, cell)
+	}
+
+	if language == "" {
+		language = defaultIPYNBLanguage
+	}
+	if _, ok := ipynb.Metadata[ipynbMetaLanguageInfo]; !ok {
+		ipynb.Metadata[ipynbMetaLanguageInfo] = map[string]interface{}{
+			"name": language,
+		}
+	}
+
+	return &ipynb, nil
+}
+
+// MarshalJSON marshals code cells with the required outputs and execution_count fields.
+func (c ipynbCell) MarshalJSON() ([]byte, error) {
+	type cell ipynbCell
+
+	if c.CellType != ipynbCellCode {
+		return json.Marshal(cell(c))
+	}
+
+	outputs := c.Outputs
+	if outputs == nil {
+		outputs = []json.RawMessage{}
+	}
+
+	return json.Marshal(struct {
+		cell
+
+		Outputs        []json.RawMessage `json:"outputs"`
+		ExecutionCount *int              `json:"execution_count"`
+	}{
+		cell:           cell(c),
+		Outputs:        outputs,
+		ExecutionCount: c.ExecutionCount,
+	})
+}
+
+func ipynbLanguage(meta map[string]interface{}) string {
+	if info, ok := meta[ipynbMetaLanguageInfo].(map[string]interface{}); ok {
+		if name, ok := info["name"].(string); ok && name != "" {
+			return name
+		}
+	}
+
+	if spec, ok := meta[ipynbMetaKernelSpec].(map[string]interface{}); ok {
+		if language, ok := spec["language"].(string); ok && language != "" {
+			return language
+		}
+	}
+
+	return defaultIPYNBLanguage
+}
@@ -0,0 +1,298 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/MonkeyBuisness/celli/notebook/runner"
+	"github.com/MonkeyBuisness/celli/notebook/types"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ipynbOutputStream        = "stream"
+	ipynbOutputExecuteResult = "execute_result"
+	ipynbOutputDisplayData   = "display_data"
+	ipynbOutputError         = "error"
+
+	ipynbStreamStdout = "stdout"
+	ipynbStreamStderr = "stderr"
+
+	// mimeError is the MIME type of the error output item (VS Code notebook format).
+	mimeError = "application/vnd.code.notebook.error"
+
+	// Output metadata keys that keep ipynb specific fields of the output.
+	outputMetaOutputType     = "outputType"
+	outputMetaExecutionCount = "executionCount"
+)
+
+type ipynbOutput struct {
+	OutputType     string                     `json:"output_type"`
+	Name           string                     `json:"name"`
+	Text           ipynbSource                `json:"text"`
+	Data           map[string]json.RawMessage `json:"data"`
+	Metadata       map[string]interface{}     `json:"metadata"`
+	ExecutionCount *int                       `json:"execution_count"`
+	EName          string                     `json:"ename"`
+	EValue         string                     `json:"evalue"`
+	Traceback      []string                   `json:"traceback"`
+}
+
+// MarshalJSON marshals only the fields of the output type.
+func (o ipynbOutput) MarshalJSON() ([]byte, error) {
+	switch o.OutputType {
+	case ipynbOutputStream:
+		return json.Marshal(struct {
+			OutputType string      `json:"output_type"`
+			Name       string      `json:"name"`
+			Text       ipynbSource `json:"text"`
+		}{o.OutputType, o.Name, o.Text})
+	case ipynbOutputError:
+		traceback := o.Traceback
+		if traceback == nil {
+			traceback = []string{}
+		}
+
+		return json.Marshal(struct {
+			OutputType string   `json:"output_type"`
+			EName      string   `json:"ename"`
+			EValue     string   `json:"evalue"`
+			Traceback  []string `json:"traceback"`
+		}{o.OutputType, o.EName, o.EValue, traceback})
+	case ipynbOutputExecuteResult:
+		return json.Marshal(struct {
+			OutputType     string                     `json:"output_type"`
+			Data           map[string]json.RawMessage `json:"data"`
+			Metadata       map[string]interface{}     `json:"metadata"`
+			ExecutionCount *int                       `json:"execution_count"`
+		}{o.OutputType, o.Data, o.Metadata, o.ExecutionCount})
+	}
+
+	return json.Marshal(struct {
+		OutputType string                     `json:"output_type"`
+		Data       map[string]json.RawMessage `json:"data"`
+		Metadata   map[string]interface{}     `json:"metadata"`
+	}{o.OutputType, o.Data, o.Metadata})
+}
+
+// outputError represents the data of the error output item.
+type outputError struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	Stack   string `json:"stack,omitempty"`
+}
+
+// createCellOutputs converts ipynb outputs of the cell to the cell outputs, unknown outputs are skipped.
+func createCellOutputs(cell int, outputs []json.RawMessage) ([]types.NotebookCellOutput, error) {
+	var result []types.NotebookCellOutput
+	for i, data := range outputs {
+		var o ipynbOutput
+		if err := json.Unmarshal(data, &o); err != nil {
+			return nil, fmt.Errorf("cell %d: output %d: %v", cell, i, err)
+		}
+
+		output, err := createCellOutput(&o)
+		if err != nil {
+			return nil, fmt.Errorf("cell %d: output %d: %v", cell, i, err)
+		}
+		if output == nil {
+			logrus.Warnf("cell %d: output %d of unknown type %q will be dropped", cell, i, o.OutputType)
+			continue
+		}
+
+		result = append(result, *output)
+	}
+
+	return result, nil
+}
+
+func createCellOutput(o *ipynbOutput) (*types.NotebookCellOutput, error) {
+	switch o.OutputType {
+	case ipynbOutputStream:
+		mime := runner.MimeStdout
+		if o.Name == ipynbStreamStderr {
+			mime = runner.MimeStderr
+		}
+
+		return &types.NotebookCellOutput{
+			Items: []types.NotebookCellOutputItem{{Mime: mime, Data: string(o.Text)}},
+		}, nil
+	case ipynbOutputExecuteResult, ipynbOutputDisplayData:
+		output := types.NotebookCellOutput{
+			Items: make([]types.NotebookCellOutputItem, 0, len(o.Data)),
+			Metadata: map[string]interface{}{
+				outputMetaOutputType: o.OutputType,
+			},
+		}
+		for key, value := range o.Metadata {
+			output.Metadata[key] = value
+		}
+		if o.ExecutionCount != nil {
+			output.Metadata[outputMetaExecutionCount] = *o.ExecutionCount
+		}
+
+		mimes := make([]string, 0, len(o.Data))
+		for mime := range o.Data {
+			mimes = append(mimes, mime)
+		}
+		sort.Strings(mimes)
+
+		for _, mime := range mimes {
+			output.Items = append(output.Items, types.NotebookCellOutputItem{
+				Mime: mime,
+				Data: outputItemData(o.Data[mime]),
+			})
+		}
+
+		return &output, nil
+	case ipynbOutputError:
+		data, err := json.Marshal(outputError{
+			Name:    o.EName,
+			Message: o.EValue,
+			Stack:   strings.Join(o.Traceback, "\n"),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &types.NotebookCellOutput{
+			Items: []types.NotebookCellOutputItem{{Mime: mimeError, Data: string(data)}},
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// outputItemData returns multiline string data as is and the other JSON values (e.g. application/json) encoded.
+func outputItemData(data json.RawMessage) string {
+	var source ipynbSource
+	if err := json.Unmarshal(data, &source); err == nil {
+		return string(source)
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return string(data)
+	}
+
+	return compact.String()
+}
+
+// createIPYNBOutputs converts cell outputs to ipynb outputs.
+//
+// Standard streams and errors become the separate outputs, the other items of the output are kept together.
+func createIPYNBOutputs(outputs []types.NotebookCellOutput) ([]json.RawMessage, error) {
+	result := make([]json.RawMessage, 0, len(outputs))
+	for i := range outputs {
+		ipynbOutputs, err := createIPYNBOutput(&outputs[i])
+		if err != nil {
+			return nil, fmt.Errorf("output %d: %v", i, err)
+		}
+
+		for j := range ipynbOutputs {
+			data, err := json.Marshal(&ipynbOutputs[j])
+			if err != nil {
+				return nil, fmt.Errorf("output %d: %v", i, err)
+			}
+			result = append(result, data)
+		}
+	}
+
+	return result, nil
+}
+
+func createIPYNBOutput(output *types.NotebookCellOutput) ([]ipynbOutput, error) {
+	var result []ipynbOutput
+	rich := -1
+	for _, item := range output.Items {
+		switch item.Mime {
+		case runner.MimeStdout, runner.MimeStderr:
+			name := ipynbStreamStdout
+			if item.Mime == runner.MimeStderr {
+				name = ipynbStreamStderr
+			}
+
+			result = append(result, ipynbOutput{
+				OutputType: ipynbOutputStream,
+				Name:       name,
+				Text:       ipynbSource(item.Data),
+			})
+		case mimeError:
+			var outErr outputError
+			if err := json.Unmarshal([]byte(item.Data), &outErr); err != nil {
+				return nil, fmt.Errorf("could not parse error output: %v", err)
+			}
+
+			var traceback []string
+			if outErr.Stack != "" {
+				traceback = strings.Split(outErr.Stack, "\n")
+			}
+			result = append(result, ipynbOutput{
+				OutputType: ipynbOutputError,
+				EName:      outErr.Name,
+				EValue:     outErr.Message,
+				Traceback:  traceback,
+			})
+		default:
+			if rich == -1 {
+				rich = len(result)
+				result = append(result, newIPYNBRichOutput(output.Metadata))
+			}
+
+			data, err := ipynbItemData(&item)
+			if err != nil {
+				return nil, err
+			}
+			result[rich].Data[item.Mime] = data
+		}
+	}
+
+	return result, nil
+}
+
+// newIPYNBRichOutput returns display_data or execute_result output with the metadata of the cell output.
+func newIPYNBRichOutput(meta map[string]interface{}) ipynbOutput {
+	o := ipynbOutput{
+		OutputType: ipynbOutputDisplayData,
+		Data:       make(map[string]json.RawMessage),
+		Metadata:   make(map[string]interface{}),
+	}
+	for key, value := range meta {
+		switch key {
+		case outputMetaOutputType:
+			if value == ipynbOutputExecuteResult {
+				o.OutputType = ipynbOutputExecuteResult
+			}
+		case outputMetaExecutionCount:
+		default:
+			o.Metadata[key] = value
+		}
+	}
+
+	if o.OutputType == ipynbOutputExecuteResult {
+		o.ExecutionCount = new(int)
+		if count, ok := meta[outputMetaExecutionCount].(float64); ok {
+			*o.ExecutionCount = int(count)
+		} else if count, ok := meta[outputMetaExecutionCount].(int); ok {
+			*o.ExecutionCount = count
+		}
+	}
+
+	return o
+}
+
+// ipynbItemData returns JSON data of the JSON MIME types as is and the others as multiline strings.
+func ipynbItemData(item *types.NotebookCellOutputItem) (json.RawMessage, error) {
+	if isJSONMime(item.Mime) && json.Valid([]byte(item.Data)) {
+		return json.RawMessage(item.Data), nil
+	}
+
+	return json.Marshal(ipynbSource(item.Data))
+}
+
+func isJSONMime(mime string) bool {
+	return mime == "application/json" || strings.HasSuffix(mime, "+json")
+}
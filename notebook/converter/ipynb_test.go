@@ -0,0 +1,161 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/MonkeyBuisness/celli/notebook/types"
+	"github.com/stretchr/testify/require"
+)
+
+const testIPYNB = `{
+	"cells": [
+		{"cell_type": "markdown", "metadata": {}, "source": ["# Title\n", "text"]},
+		{
+			"id": "cell-1",
+			"cell_type": "code",
+			"metadata": {"is-executable": false},
+			"source": "System.out.println(1);",
+			"execution_count": 1,
+			"outputs": [
+				{"output_type": "stream", "name": "stdout", "text": ["1\n"]},
+				{
+					"output_type": "execute_result",
+					"execution_count": 1,
+					"data": {"text/plain": ["1\n", "2"], "application/json": {"a": 1}},
+					"metadata": {}
+				},
+				{"output_type": "error", "ename": "Exception", "evalue": "fail", "traceback": ["at 1", "at 2"]}
+			]
+		},
+		{"cell_type": "raw", "metadata": {}, "source": "raw text"}
+	],
+	"metadata": {"kernelspec": {"name": "java", "language": "java"}},
+	"nbformat": 4,
+	"nbformat_minor": 5
+}`
+
+func Test_ProceedIPYNB(t *testing.T) {
+	t.Run("parse error", func(t *testing.T) {
+		_, err := ProceedIPYNB(strings.NewReader("{"))
+		require.Error(t, err)
+	})
+	t.Run("unsupported version", func(t *testing.T) {
+		_, err := ProceedIPYNB(strings.NewReader(`{"nbformat": 3}`))
+		require.EqualError(t, err, "could not parse notebook content: unsupported nbformat version 3")
+	})
+	t.Run("all ok", func(t *testing.T) {
+		notebook, err := ProceedIPYNB(strings.NewReader(testIPYNB))
+		require.NoError(t, err)
+		require.Equal(t, []types.NotebookCellData{
+			{
+				LanguageID: types.MarkdownLanguageID,
+				Content:    "# Title\ntext",
+				Kind:       types.NotebookCellKindMarkup,
+			},
+			{
+				LanguageID: "java",
+				Content:    "System.out.println(1);",
+				Kind:       types.NotebookCellKindCode,
+				Metadata: map[string]interface{}{
+					"is-executable":         false,
+					types.CellIDMetadataKey: "cell-1",
+				},
+				Outputs: []types.NotebookCellOutput{
+					{
+						Items: []types.NotebookCellOutputItem{
+							{Mime: "application/vnd.code.notebook.stdout", Data: "1\n"},
+						},
+					},
+					{
+						Items: []types.NotebookCellOutputItem{
+							{Mime: "application/json", Data: `{"a":1}`},
+							{Mime: "text/plain", Data: "1\n2"},
+						},
+						Metadata: map[string]interface{}{
+							outputMetaOutputType:     "execute_result",
+							outputMetaExecutionCount: 1,
+						},
+					},
+					{
+						Items: []types.NotebookCellOutputItem{
+							{Mime: mimeError, Data: `{"name":"Exception","message":"fail","stack":"at 1\nat 2"}`},
+						},
+					},
+				},
+			},
+			{
+				LanguageID: RawLanguageID,
+				Content:    "raw text",
+				Kind:       types.NotebookCellKindMarkup,
+			},
+		}, notebook.Cells)
+		require.Contains(t, notebook.Metadata, "kernelspec")
+	})
+}
+
+func Test_CreateIPYNB(t *testing.T) {
+	notebook, err := ProceedIPYNB(strings.NewReader(testIPYNB))
+	require.NoError(t, err)
+
+	data, err := json.Marshal(notebook)
+	require.NoError(t, err)
+
+	ipynbData, err := CreateIPYNB(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	var ipynb map[string]interface{}
+	require.NoError(t, json.Unmarshal(ipynbData, &ipynb))
+	require.EqualValues(t, ipynbFormat, ipynb["nbformat"])
+
+	require.EqualValues(t, ipynbFormatMinorIDs, ipynb["nbformat_minor"])
+
+	cells := ipynb["cells"].([]interface{})
+	require.Len(t, cells, 3)
+	require.Equal(t, map[string]interface{}{
+		"cell_type": "markdown",
+		"metadata":  map[string]interface{}{},
+		"source":    []interface{}{"# Title\n", "text"},
+	}, cells[0])
+	require.Equal(t, map[string]interface{}{
+		"id":        "cell-1",
+		"cell_type": "code",
+		"metadata":  map[string]interface{}{"is-executable": false},
+		"source":    []interface{}{"System.out.println(1);"},
+		"outputs": []interface{}{
+			map[string]interface{}{
+				"output_type": "stream",
+				"name":        "stdout",
+				"text":        []interface{}{"1\n"},
+			},
+			map[string]interface{}{
+				"output_type":     "execute_result",
+				"execution_count": float64(1),
+				"data": map[string]interface{}{
+					"text/plain":       []interface{}{"1\n", "2"},
+					"application/json": map[string]interface{}{"a": float64(1)},
+				},
+				"metadata": map[string]interface{}{},
+			},
+			map[string]interface{}{
+				"output_type": "error",
+				"ename":       "Exception",
+				"evalue":      "fail",
+				"traceback":   []interface{}{"at 1", "at 2"},
+			},
+		},
+		"execution_count": nil,
+	}, cells[1])
+	require.Equal(t, map[string]interface{}{
+		"cell_type": "raw",
+		"metadata":  map[string]interface{}{},
+		"source":    []interface{}{"raw text"},
+	}, cells[2])
+
+	// round trip.
+	roundTrip, err := ProceedIPYNB(bytes.NewReader(ipynbData))
+	require.NoError(t, err)
+	require.Equal(t, notebook.Cells, roundTrip.Cells)
+}
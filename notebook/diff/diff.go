@@ -0,0 +1,413 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/MonkeyBuisness/celli/notebook/types"
+)
+
+// similarityThreshold is the minimal similarity of the cells content to align them as the modified cell.
+const similarityThreshold = 0.5
+
+// Cell change kind.
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeMoved    ChangeKind = "moved"
+	ChangeModified ChangeKind = "modified"
+)
+
+// ChangeKind represents kind of the cell change.
+type ChangeKind string
+
+// Result represents semantic difference of two notebooks.
+type Result struct {
+	Metadata []MetadataChange `json:"metadata,omitempty"`
+	Cells    []CellChange     `json:"cells,omitempty"`
+}
+
+// CellChange represents change of the cell.
+//
+// Cell numbers are 1-based, zero number means the cell is absent in the notebook.
+type CellChange struct {
+	Kind           ChangeKind       `json:"kind"`
+	OldIndex       int              `json:"oldIndex,omitempty"`
+	NewIndex       int              `json:"newIndex,omitempty"`
+	ID             string           `json:"id,omitempty"`
+	Moved          bool             `json:"moved,omitempty"`
+	Content        string           `json:"content,omitempty"`
+	ContentDiff    string           `json:"contentDiff,omitempty"`
+	Metadata       []MetadataChange `json:"metadataChanges,omitempty"`
+	OutputsChanged bool             `json:"outputsChanged,omitempty"`
+}
+
+// MetadataChange represents change of the metadata key.
+//
+// Old value is nil for the added keys, new value is nil for the removed keys.
+type MetadataChange struct {
+	Key string      `json:"key"`
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+}
+
+// Empty reports whether the notebooks are equal.
+func (r *Result) Empty() bool {
+	return len(r.Metadata) == 0 && len(r.Cells) == 0
+}
+
+// Compare aligns cells of the notebooks and returns the difference between them.
+//
+// Cells are aligned by the ID if it is present in the both notebooks,
+// otherwise by the equal content and then by the content similarity.
+func Compare(oldNotebook, newNotebook *types.NotebookData) *Result {
+	oldCells, newCells := oldNotebook.Cells, newNotebook.Cells
+	oldToNew := Align(oldCells, newCells)
+
+	newToOld := make([]int, len(newCells))
+	for i := range newToOld {
+		newToOld[i] = -1
+	}
+	for o, n := range oldToNew {
+		if n != -1 {
+			newToOld[n] = o
+		}
+	}
+
+	moved := movedCells(oldToNew)
+
+	type orderedChange struct {
+		position float64
+		change   CellChange
+	}
+	var changes []orderedChange
+
+	// removed cells are placed after the new position of the previous aligned cell.
+	anchor := -1
+	for o, n := range oldToNew {
+		if n != -1 {
+			anchor = n
+			continue
+		}
+
+		changes = append(changes, orderedChange{
+			position: float64(anchor) + 0.5,
+			change: CellChange{
+				Kind:     ChangeRemoved,
+				OldIndex: o + 1,
+				ID:       oldCells[o].ID(),
+				Content:  oldCells[o].Content,
+			},
+		})
+	}
+
+	for n, o := range newToOld {
+		if o == -1 {
+			changes = append(changes, orderedChange{
+				position: float64(n),
+				change: CellChange{
+					Kind:     ChangeAdded,
+					NewIndex: n + 1,
+					ID:       newCells[n].ID(),
+					Content:  newCells[n].Content,
+				},
+			})
+			continue
+		}
+
+		if change, ok := compareCells(o, n, &oldCells[o], &newCells[n], moved[o]); ok {
+			changes = append(changes, orderedChange{
+				position: float64(n),
+				change:   change,
+			})
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].position < changes[j].position
+	})
+
+	result := Result{
+		Metadata: compareMetadata(oldNotebook.Metadata, newNotebook.Metadata),
+	}
+	for _, c := range changes {
+		result.Cells = append(result.Cells, c.change)
+	}
+
+	return &result
+}
+
+func compareCells(o, n int, oldCell, newCell *types.NotebookCellData, moved bool) (CellChange, bool) {
+	change := CellChange{
+		Kind:     ChangeModified,
+		OldIndex: o + 1,
+		NewIndex: n + 1,
+		ID:       newCell.ID(),
+		Moved:    moved,
+		ContentDiff: Unified(
+			fmt.Sprintf("a/cell %d", o+1), fmt.Sprintf("b/cell %d", n+1), oldCell.Content, newCell.Content),
+		Metadata:       compareMetadata(cellMetadata(oldCell), cellMetadata(newCell)),
+		OutputsChanged: outputsChanged(oldCell.Outputs, newCell.Outputs),
+	}
+
+	if change.ContentDiff == "" && len(change.Metadata) == 0 && !change.OutputsChanged {
+		if !moved {
+			return CellChange{}, false
+		}
+		change.Kind, change.Moved = ChangeMoved, false
+	}
+
+	return change, true
+}
+
+func outputsChanged(oldOutputs, newOutputs []types.NotebookCellOutput) bool {
+	if len(oldOutputs) == 0 && len(newOutputs) == 0 {
+		return false
+	}
+
+	return !reflect.DeepEqual(normalize(oldOutputs), normalize(newOutputs))
+}
+
+// cellMetadata returns cell metadata with the language and the kind of the cell,
+// so their changes are reported as the metadata changes.
+func cellMetadata(cell *types.NotebookCellData) map[string]interface{} {
+	meta := make(map[string]interface{}, len(cell.Metadata)+2)
+	for key, value := range cell.Metadata {
+		meta[key] = value
+	}
+	meta["languageId"] = cell.LanguageID
+	meta["kind"] = int(cell.Kind)
+
+	return meta
+}
+
+func compareMetadata(oldMeta, newMeta map[string]interface{}) []MetadataChange {
+	keys := make(map[string]struct{}, len(oldMeta)+len(newMeta))
+	for key := range oldMeta {
+		keys[key] = struct{}{}
+	}
+	for key := range newMeta {
+		keys[key] = struct{}{}
+	}
+
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	var changes []MetadataChange
+	for _, key := range sortedKeys {
+		oldValue, newValue := normalize(oldMeta[key]), normalize(newMeta[key])
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+
+		changes = append(changes, MetadataChange{
+			Key: key,
+			Old: oldValue,
+			New: newValue,
+		})
+	}
+
+	return changes
+}
+
+// normalize converts the value to its JSON form, so equal values of the different Go types compare equal.
+func normalize(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return value
+	}
+
+	return normalized
+}
+
+// Align returns the index of the aligned new cell for every old cell or -1 if there is no one.
+func Align(oldCells, newCells []types.NotebookCellData) []int {
+	oldToNew := make([]int, len(oldCells))
+	for i := range oldToNew {
+		oldToNew[i] = -1
+	}
+	usedNew := make([]bool, len(newCells))
+
+	pair := func(o, n int) {
+		oldToNew[o] = n
+		usedNew[n] = true
+	}
+
+	// cells with the same unique ID.
+	newIDs := uniqueIDs(newCells)
+	oldIDs := uniqueIDs(oldCells)
+	for id, o := range oldIDs {
+		if n, ok := newIDs[id]; ok {
+			pair(o, n)
+		}
+	}
+
+	// cells with the equal content.
+	equal := make(map[string][]int)
+	for n := range newCells {
+		if !usedNew[n] {
+			key := cellKey(&newCells[n])
+			equal[key] = append(equal[key], n)
+		}
+	}
+	for o := range oldCells {
+		if oldToNew[o] != -1 {
+			continue
+		}
+
+		key := cellKey(&oldCells[o])
+		if candidates := equal[key]; len(candidates) != 0 {
+			pair(o, candidates[0])
+			equal[key] = candidates[1:]
+		}
+	}
+
+	// the most similar cells of the same kind.
+	type candidate struct {
+		o, n       int
+		similarity float64
+	}
+	var candidates []candidate
+	for o := range oldCells {
+		if oldToNew[o] != -1 {
+			continue
+		}
+
+		for n := range newCells {
+			if usedNew[n] || oldCells[o].Kind != newCells[n].Kind {
+				continue
+			}
+
+			if s := similarity(oldCells[o].Content, newCells[n].Content); s >= similarityThreshold {
+				candidates = append(candidates, candidate{o: o, n: n, similarity: s})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].similarity != candidates[j].similarity {
+			return candidates[i].similarity > candidates[j].similarity
+		}
+		return abs(candidates[i].o-candidates[i].n) < abs(candidates[j].o-candidates[j].n)
+	})
+	for _, c := range candidates {
+		if oldToNew[c.o] == -1 && !usedNew[c.n] {
+			pair(c.o, c.n)
+		}
+	}
+
+	return oldToNew
+}
+
+func uniqueIDs(cells []types.NotebookCellData) map[string]int {
+	ids := make(map[string]int, len(cells))
+	duplicates := make(map[string]struct{})
+	for i := range cells {
+		id := cells[i].ID()
+		if id == "" {
+			continue
+		}
+
+		if _, ok := ids[id]; ok {
+			duplicates[id] = struct{}{}
+		}
+		ids[id] = i
+	}
+
+	for id := range duplicates {
+		delete(ids, id)
+	}
+
+	return ids
+}
+
+func cellKey(cell *types.NotebookCellData) string {
+	return fmt.Sprintf("%d\x00%s\x00%s", cell.Kind, cell.LanguageID, cell.Content)
+}
+
+// similarity returns Sørensen–Dice coefficient of the words of the texts.
+func similarity(a, b string) float64 {
+	wordsA, wordsB := strings.Fields(a), strings.Fields(b)
+	if len(wordsA)+len(wordsB) == 0 {
+		return 1
+	}
+
+	counts := make(map[string]int, len(wordsA))
+	for _, w := range wordsA {
+		counts[w]++
+	}
+
+	common := 0
+	for _, w := range wordsB {
+		if counts[w] > 0 {
+			counts[w]--
+			common++
+		}
+	}
+
+	return 2 * float64(common) / float64(len(wordsA)+len(wordsB))
+}
+
+// movedCells returns the old cells that are aligned out of order:
+// the cells that are not in the longest increasing sequence of the new indices stay in place.
+func movedCells(oldToNew []int) map[int]bool {
+	var aligned []int
+	for o, n := range oldToNew {
+		if n != -1 {
+			aligned = append(aligned, o)
+		}
+	}
+
+	// tails[k] is the position in aligned of the smallest tail of the increasing sequence of length k+1.
+	tails := make([]int, 0, len(aligned))
+	prev := make([]int, len(aligned))
+	for i, o := range aligned {
+		k := sort.Search(len(tails), func(k int) bool {
+			return oldToNew[aligned[tails[k]]] >= oldToNew[o]
+		})
+
+		prev[i] = -1
+		if k > 0 {
+			prev[i] = tails[k-1]
+		}
+
+		if k == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[k] = i
+		}
+	}
+
+	inPlace := make(map[int]bool, len(tails))
+	if len(tails) != 0 {
+		for i := tails[len(tails)-1]; i != -1; i = prev[i] {
+			inPlace[aligned[i]] = true
+		}
+	}
+
+	moved := make(map[int]bool)
+	for _, o := range aligned {
+		if !inPlace[o] {
+			moved[o] = true
+		}
+	}
+
+	return moved
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
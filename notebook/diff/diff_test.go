@@ -0,0 +1,113 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/MonkeyBuisness/celli/notebook/types"
+	"github.com/stretchr/testify/require"
+)
+
+func markup(content string) types.NotebookCellData {
+	return types.NotebookCellData{
+		LanguageID: types.MarkdownLanguageID,
+		Content:    content,
+		Kind:       types.NotebookCellKindMarkup,
+	}
+}
+
+func code(content string, meta map[string]interface{}) types.NotebookCellData {
+	return types.NotebookCellData{
+		LanguageID: "java",
+		Content:    content,
+		Kind:       types.NotebookCellKindCode,
+		Metadata:   meta,
+	}
+}
+
+func TestCompare(t *testing.T) {
+	t.Run("equal notebooks", func(t *testing.T) {
+		notebook := &types.NotebookData{
+			Cells: []types.NotebookCellData{markup("# Intro"), code("class Main {}", nil)},
+		}
+
+		require.True(t, Compare(notebook, notebook).Empty())
+	})
+
+	t.Run("added, removed, moved and modified cells", func(t *testing.T) {
+		oldNotebook := &types.NotebookData{
+			Metadata: map[string]interface{}{"title": "Loops"},
+			Cells: []types.NotebookCellData{
+				markup("# Intro"),
+				code("int a = 1;\nint b = 2;\nSystem.out.println(a + b);", nil),
+				markup("## Removed section"),
+				markup("## Summary of the loops"),
+			},
+		}
+		newNotebook := &types.NotebookData{
+			Metadata: map[string]interface{}{"title": "Loops in Java"},
+			Cells: []types.NotebookCellData{
+				markup("## Summary of the loops"),
+				markup("# Intro"),
+				code("int a = 1;\nint b = 20;\nSystem.out.println(a + b);", map[string]interface{}{
+					"is-executable": "false",
+				}),
+				code("class New {}", nil),
+			},
+		}
+
+		result := Compare(oldNotebook, newNotebook)
+		require.Equal(t, []MetadataChange{
+			{Key: "title", Old: "Loops", New: "Loops in Java"},
+		}, result.Metadata)
+
+		require.Len(t, result.Cells, 4)
+		require.Equal(t, CellChange{Kind: ChangeMoved, OldIndex: 4, NewIndex: 1}, result.Cells[0])
+
+		modified := result.Cells[1]
+		require.Equal(t, ChangeModified, modified.Kind)
+		require.Equal(t, 2, modified.OldIndex)
+		require.Equal(t, 3, modified.NewIndex)
+		require.Equal(t, "--- a/cell 2\n+++ b/cell 3\n@@ -1,3 +1,3 @@\n int a = 1;\n-int b = 2;\n+int b = 20;\n"+
+			" System.out.println(a + b);\n", modified.ContentDiff)
+		require.Equal(t, []MetadataChange{{Key: "is-executable", New: "false"}}, modified.Metadata)
+
+		require.Equal(t, CellChange{Kind: ChangeRemoved, OldIndex: 3, Content: "## Removed section"}, result.Cells[2])
+		require.Equal(t, CellChange{Kind: ChangeAdded, NewIndex: 4, Content: "class New {}"}, result.Cells[3])
+	})
+
+	t.Run("cells aligned by ID", func(t *testing.T) {
+		oldNotebook := &types.NotebookData{
+			Cells: []types.NotebookCellData{
+				code("class A {}", map[string]interface{}{"id": "a"}),
+				code("class B {}", map[string]interface{}{"id": "b"}),
+			},
+		}
+		newNotebook := &types.NotebookData{
+			Cells: []types.NotebookCellData{
+				code("class B {}", map[string]interface{}{"id": "a"}),
+				code("class A {}", map[string]interface{}{"id": "b"}),
+			},
+		}
+
+		result := Compare(oldNotebook, newNotebook)
+		require.Len(t, result.Cells, 2)
+		for i, c := range result.Cells {
+			require.Equal(t, ChangeModified, c.Kind)
+			require.Equal(t, i+1, c.OldIndex)
+			require.Equal(t, i+1, c.NewIndex)
+			require.False(t, c.Moved)
+		}
+	})
+}
+
+func TestUnified(t *testing.T) {
+	require.Empty(t, Unified("a", "b", "same", "same"))
+
+	oldText := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12"
+	newText := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11"
+	require.Equal(t, "--- a\n+++ b\n"+
+		"@@ -1,6 +1,6 @@\n 1\n 2\n-3\n+three\n 4\n 5\n 6\n"+
+		"@@ -9,4 +9,3 @@\n 9\n 10\n 11\n-12\n", Unified("a", "b", oldText, newText))
+
+	require.Equal(t, "--- a\n+++ b\n@@ -0,0 +1 @@\n+new\n", Unified("a", "b", "", "new"))
+}
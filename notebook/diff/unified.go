@@ -0,0 +1,179 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines around the changes in the unified diff.
+const contextLines = 3
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type lineOp struct {
+	kind opKind
+	line string
+}
+
+// Unified returns unified diff of the texts line by line or an empty string if they are equal.
+func Unified(oldName, newName, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+
+	ops := diffLines(splitLines(oldText), splitLines(newText))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+	for _, h := range hunks(ops) {
+		writeHunk(&b, ops, h)
+	}
+
+	return b.String()
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines returns the edit script of the longest common subsequence of the lines.
+func diffLines(a, b []string) []lineOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	ops := make([]lineOp, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, lineOp{kind: opEqual, line: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, lineOp{kind: opDelete, line: a[i]})
+			i++
+		default:
+			ops = append(ops, lineOp{kind: opInsert, line: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, lineOp{kind: opDelete, line: a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, lineOp{kind: opInsert, line: b[j]})
+	}
+
+	return ops
+}
+
+type hunk struct {
+	from int
+	to   int
+}
+
+// hunks groups the changed operations with their context, close groups are merged.
+func hunks(ops []lineOp) []hunk {
+	var result []hunk
+	for i, op := range ops {
+		if op.kind == opEqual {
+			continue
+		}
+
+		from, to := max(i-contextLines, 0), min(i+contextLines+1, len(ops))
+		if n := len(result); n != 0 && from <= result[n-1].to {
+			result[n-1].to = to
+			continue
+		}
+		result = append(result, hunk{from: from, to: to})
+	}
+
+	return result
+}
+
+func writeHunk(b *strings.Builder, ops []lineOp, h hunk) {
+	// line numbers of the hunk start in the old and the new text.
+	oldLine, newLine := 1, 1
+	for _, op := range ops[:h.from] {
+		if op.kind != opInsert {
+			oldLine++
+		}
+		if op.kind != opDelete {
+			newLine++
+		}
+	}
+
+	oldCount, newCount := 0, 0
+	for _, op := range ops[h.from:h.to] {
+		if op.kind != opInsert {
+			oldCount++
+		}
+		if op.kind != opDelete {
+			newCount++
+		}
+	}
+
+	fmt.Fprintf(b, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+	for _, op := range ops[h.from:h.to] {
+		switch op.kind {
+		case opEqual:
+			b.WriteString(" ")
+		case opDelete:
+			b.WriteString("-")
+		case opInsert:
+			b.WriteString("+")
+		}
+		b.WriteString(op.line)
+		b.WriteString("\n")
+	}
+}
+
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		// empty range points to the line before the change.
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprintf("%d", start)
+	default:
+		return fmt.Sprintf("%d,%d", start, count)
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
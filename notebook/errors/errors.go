@@ -32,6 +32,12 @@ var (
 	ErrCreateTemplateContent = Error{
 		base: errors.New("could not create template content"),
 	}
+	ErrInvalidTemplate = Error{
+		base: errors.New("invalid template"),
+	}
+	ErrCreateIPYNBContent = Error{
+		base: errors.New("could not create ipynb content"),
+	}
 )
 
 // New creates a new copy of Error.
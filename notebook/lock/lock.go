@@ -0,0 +1,239 @@
+package lock
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/MonkeyBuisness/celli/notebook/resolver"
+	"github.com/MonkeyBuisness/celli/notebook/types"
+	"github.com/MonkeyBuisness/celli/notebook/utils"
+)
+
+const (
+	// FileName is the name of the lockfile.
+	FileName = "celli.lock"
+
+	fileVersion = 1
+)
+
+// File represents lockfile model that keeps SHA-256 hashes of the remote URIs.
+//
+// The lockfile is shared by the templates of the directory, so the URIs recorded for every template
+// (by the file name) are kept to prune only the URIs no template references anymore.
+type File struct {
+	Version   int                 `json:"version"`
+	URIs      map[string]string   `json:"uris"`
+	Templates map[string][]string `json:"templates,omitempty"`
+}
+
+// Recorder represents URI resolver that records hashes of the resolved remote URIs.
+type Recorder struct {
+	base types.URIResolver
+
+	mu     sync.Mutex
+	hashes map[string]string
+}
+
+// Enforcer represents URI resolver that verifies resolved remote URIs against the lockfile.
+type Enforcer struct {
+	base types.URIResolver
+	file *File
+}
+
+// NewFile returns new empty lockfile.
+func NewFile() *File {
+	return &File{
+		Version:   fileVersion,
+		URIs:      make(map[string]string),
+		Templates: make(map[string][]string),
+	}
+}
+
+// Read reads lockfile from the path.
+func Read(path string) (*File, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+
+	f := NewFile()
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("could not parse lockfile: %v", err)
+	}
+
+	if f.Version != fileVersion {
+		return nil, fmt.Errorf("unsupported lockfile version %d", f.Version)
+	}
+
+	if f.URIs == nil {
+		f.URIs = make(map[string]string)
+	}
+	if f.Templates == nil {
+		f.Templates = make(map[string][]string)
+	}
+
+	return f, nil
+}
+
+// Write writes lockfile to the path.
+func (f *File) Write(path string) error {
+	data, err := json.MarshalIndent(f, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return utils.WriteFileAtomic(path, append(data, '\n'), types.DefaultFileMode)
+}
+
+// Record replaces the URIs recorded for the template with the hashed ones.
+//
+// URIs the template recorded before are removed if no other template references them,
+// URIs that are not recorded for any template (e.g. added by hand) are kept.
+func (f *File) Record(template string, hashes map[string]string) {
+	prevURIs := f.Templates[template]
+
+	uris := make([]string, 0, len(hashes))
+	for uri, hash := range hashes {
+		uris = append(uris, uri)
+		f.URIs[uri] = hash
+	}
+	sort.Strings(uris)
+
+	if len(uris) == 0 {
+		delete(f.Templates, template)
+	} else {
+		f.Templates[template] = uris
+	}
+
+	for _, uri := range prevURIs {
+		if _, ok := hashes[uri]; !ok && !f.isReferenced(uri) {
+			delete(f.URIs, uri)
+		}
+	}
+}
+
+func (f *File) isReferenced(uri string) bool {
+	for _, uris := range f.Templates {
+		for _, u := range uris {
+			if u == uri {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Hash returns hex encoded SHA-256 hash of the data.
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify checks that SHA-256 hash of the data equals to the expected one.
+func Verify(data []byte, expected string) error {
+	if actual := Hash(data); !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("sha256 mismatch: expected %s, got %s", expected, actual)
+	}
+
+	return nil
+}
+
+// IsRemote reports whether the URI refers to the remote content.
+func IsRemote(uri string) bool {
+	switch resolver.Scheme(uri) {
+	case "", resolver.SchemeFile, resolver.SchemeData, resolver.SchemeMem:
+		return false
+	}
+
+	return true
+}
+
+// NewRecorder returns new Recorder instance.
+func NewRecorder(base types.URIResolver) *Recorder {
+	return &Recorder{
+		base:   base,
+		hashes: make(map[string]string),
+	}
+}
+
+// Resolve resolves the URI and records its hash if it is remote.
+func (r *Recorder) Resolve(uri string) ([]byte, error) {
+	data, err := r.base.Resolve(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	if IsRemote(uri) {
+		r.mu.Lock()
+		r.hashes[uri] = Hash(data)
+		r.mu.Unlock()
+	}
+
+	return data, nil
+}
+
+// Hashes returns recorded hashes keyed by URI.
+func (r *Recorder) Hashes() map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hashes := make(map[string]string, len(r.hashes))
+	for uri, hash := range r.hashes {
+		hashes[uri] = hash
+	}
+
+	return hashes
+}
+
+// NewEnforcer returns new Enforcer instance.
+func NewEnforcer(base types.URIResolver, file *File) Enforcer {
+	return Enforcer{
+		base: base,
+		file: file,
+	}
+}
+
+// Resolve resolves the URI and verifies its hash if it is remote.
+//
+// Remote URIs that are missing in the lockfile are rejected.
+func (e Enforcer) Resolve(uri string) ([]byte, error) {
+	if !IsRemote(uri) {
+		return e.base.Resolve(uri)
+	}
+
+	expected, ok := e.file.URIs[uri]
+	if !ok {
+		return nil, fmt.Errorf("%s is not locked in %s, run `celli lock` to update it", uri, FileName)
+	}
+
+	data, err := e.base.Resolve(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := Verify(data, expected); err != nil {
+		return nil, fmt.Errorf("%s: %v", FileName, err)
+	}
+
+	return data, nil
+}
+
+// ReadIfExists reads lockfile from the path or returns nil if it does not exist.
+func ReadIfExists(path string) (*File, error) {
+	f, err := Read(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	return f, err
+}
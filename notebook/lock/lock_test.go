@@ -0,0 +1,91 @@
+package lock
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/MonkeyBuisness/celli/notebook/resolver"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Verify(t *testing.T) {
+	data := []byte("class Main {}")
+
+	require.NoError(t, Verify(data, Hash(data)))
+	require.EqualError(t, Verify(data, "abc"),
+		"sha256 mismatch: expected abc, got "+Hash(data))
+}
+
+func Test_IsRemote(t *testing.T) {
+	for uri, remote := range map[string]bool{
+		"https://example.com/Main.java": true,
+		"http://example.com/Main.java":  true,
+		"file://Main.java":              false,
+		"Main.java":                     false,
+		"data:,hello":                   false,
+	} {
+		require.Equal(t, remote, IsRemote(uri), uri)
+	}
+}
+
+func TestRecorder_Resolve(t *testing.T) {
+	base := resolver.NewRegistry().Register(resolver.SchemeHTTPS, resolver.NewMemoryResolver(map[string][]byte{
+		"https://example.com/Main.java": []byte("class Main {}"),
+	})).Register(resolver.SchemeData, resolver.NewDataResolver())
+
+	r := NewRecorder(base)
+	_, err := r.Resolve("https://example.com/Main.java")
+	require.NoError(t, err)
+	_, err = r.Resolve("data:,hello")
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]string{
+		"https://example.com/Main.java": Hash([]byte("class Main {}")),
+	}, r.Hashes())
+}
+
+func TestEnforcer_Resolve(t *testing.T) {
+	mem := resolver.NewMemoryResolver(map[string][]byte{
+		"https://example.com/Main.java": []byte("class Main {}"),
+		"https://example.com/Test.java": []byte("class Test {}"),
+	})
+	base := resolver.NewRegistry().Register(resolver.SchemeHTTPS, mem)
+
+	file := NewFile()
+	file.URIs["https://example.com/Main.java"] = Hash([]byte("class Main {}"))
+	e := NewEnforcer(base, file)
+
+	t.Run("all ok", func(t *testing.T) {
+		data, err := e.Resolve("https://example.com/Main.java")
+		require.NoError(t, err)
+		require.Equal(t, "class Main {}", string(data))
+	})
+	t.Run("not locked", func(t *testing.T) {
+		_, err := e.Resolve("https://example.com/Test.java")
+		require.EqualError(t, err,
+			"https://example.com/Test.java is not locked in celli.lock, run `celli lock` to update it")
+	})
+	t.Run("changed", func(t *testing.T) {
+		mem.Set("https://example.com/Main.java", []byte("class Main { }"))
+
+		_, err := e.Resolve("https://example.com/Main.java")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "sha256 mismatch")
+	})
+}
+
+func TestFile_Write(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+
+	f, err := ReadIfExists(path)
+	require.NoError(t, err)
+	require.Nil(t, f)
+
+	f = NewFile()
+	f.URIs["https://example.com/Main.java"] = "abc"
+	require.NoError(t, f.Write(path))
+
+	read, err := Read(path)
+	require.NoError(t, err)
+	require.Equal(t, f, read)
+}
@@ -0,0 +1,379 @@
+package merge
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/MonkeyBuisness/celli/notebook/diff"
+	"github.com/MonkeyBuisness/celli/notebook/types"
+)
+
+// Conflict markers written to the content of the conflicting cells.
+const (
+	MarkerOurs   = "<<<<<<< ours"
+	MarkerSep    = "======="
+	MarkerTheirs = ">>>>>>> theirs"
+
+	deletedSuffix = " (deleted)"
+)
+
+// Conflict represents the change that could not be merged automatically.
+type Conflict struct {
+	// Cell is the 1-based number of the cell in the merged notebook, zero for the notebook metadata.
+	Cell int
+	// Message describes the conflict.
+	Message string
+}
+
+// String returns the string representation of the conflict.
+func (c Conflict) String() string {
+	if c.Cell == 0 {
+		return c.Message
+	}
+
+	return fmt.Sprintf("cell %d: %s", c.Cell, c.Message)
+}
+
+// side represents one of the merged notebooks aligned with the base notebook.
+type side struct {
+	cells  []types.NotebookCellData
+	toBase []int
+	toSide []int
+}
+
+type merger struct {
+	base   *types.NotebookData
+	ours   side
+	theirs side
+
+	cells     []types.NotebookCellData
+	conflicts []Conflict
+}
+
+// Notebooks merges the changes of ours and theirs notebooks made since the base notebook.
+//
+// Cells are aligned with the base notebook and merged one by one, the order of ours cells is kept
+// and the cells added by theirs are inserted after their preceding cell.
+// Metadata is merged key by key. Cells changed by the both sides differently are written
+// with the conflict markers in the content, conflicting metadata keys keep ours value.
+func Notebooks(base, ours, theirs *types.NotebookData) (*types.NotebookData, []Conflict) {
+	m := merger{
+		base:   base,
+		ours:   newSide(base.Cells, ours.Cells),
+		theirs: newSide(base.Cells, theirs.Cells),
+	}
+
+	metadata, keys := mergeMetadata(base.Metadata, ours.Metadata, theirs.Metadata)
+	for _, key := range keys {
+		m.conflicts = append(m.conflicts, Conflict{
+			Message: fmt.Sprintf("notebook metadata key %q is changed by both sides", key),
+		})
+	}
+
+	m.mergeCells()
+
+	return &types.NotebookData{
+		Cells:    m.cells,
+		Metadata: metadata,
+	}, m.conflicts
+}
+
+func newSide(baseCells, cells []types.NotebookCellData) side {
+	s := side{
+		cells:  cells,
+		toSide: diff.Align(baseCells, cells),
+		toBase: make([]int, len(cells)),
+	}
+	for i := range s.toBase {
+		s.toBase[i] = -1
+	}
+	for b, i := range s.toSide {
+		if i != -1 {
+			s.toBase[i] = b
+		}
+	}
+
+	return s
+}
+
+func (m *merger) mergeCells() {
+	// cells added by theirs are grouped by the base cell they follow (-1 for the beginning).
+	theirsAdded := make(map[int][]types.NotebookCellData)
+	anchor := -1
+	for i, b := range m.theirs.toBase {
+		if b != -1 {
+			anchor = b
+			continue
+		}
+		theirsAdded[anchor] = append(theirsAdded[anchor], m.theirs.cells[i])
+	}
+
+	var oursAdded []types.NotebookCellData
+	for i, b := range m.ours.toBase {
+		if b == -1 {
+			oursAdded = append(oursAdded, m.ours.cells[i])
+		}
+	}
+
+	addTheirs := func(b int) {
+		for _, cell := range theirsAdded[b] {
+			// the same cell added by the both sides is added once.
+			if index := indexOfCell(oursAdded, &cell); index != -1 {
+				oursAdded = append(oursAdded[:index], oursAdded[index+1:]...)
+				continue
+			}
+			m.cells = append(m.cells, cell)
+		}
+	}
+
+	// base cells deleted by ours are merged in the base order.
+	flushed := make([]bool, len(m.base.Cells))
+	flush := func(upTo int) {
+		for b := 0; b < upTo; b++ {
+			if flushed[b] || m.ours.toSide[b] != -1 {
+				continue
+			}
+			flushed[b] = true
+			m.mergeBaseCell(b)
+			addTheirs(b)
+		}
+	}
+
+	addTheirs(-1)
+	for i, b := range m.ours.toBase {
+		if b == -1 {
+			m.cells = append(m.cells, m.ours.cells[i])
+			continue
+		}
+
+		flush(b)
+		flushed[b] = true
+		m.mergeBaseCell(b)
+		addTheirs(b)
+	}
+	flush(len(m.base.Cells))
+}
+
+// mergeBaseCell merges ours and theirs versions of the base cell into the merged cells.
+func (m *merger) mergeBaseCell(b int) {
+	baseCell := &m.base.Cells[b]
+
+	var oursCell, theirsCell *types.NotebookCellData
+	if i := m.ours.toSide[b]; i != -1 {
+		oursCell = &m.ours.cells[i]
+	}
+	if i := m.theirs.toSide[b]; i != -1 {
+		theirsCell = &m.theirs.cells[i]
+	}
+
+	switch {
+	case oursCell == nil && theirsCell == nil:
+	case oursCell == nil:
+		if !equal(baseCell, theirsCell) {
+			m.addConflict(conflictCell(nil, theirsCell), "cell is deleted by ours and changed by theirs")
+		}
+	case theirsCell == nil:
+		if !equal(baseCell, oursCell) {
+			m.addConflict(conflictCell(oursCell, nil), "cell is changed by ours and deleted by theirs")
+		}
+	default:
+		m.mergeChangedCell(baseCell, oursCell, theirsCell)
+	}
+}
+
+func (m *merger) mergeChangedCell(baseCell, oursCell, theirsCell *types.NotebookCellData) {
+	switch choose(encode(baseCell), encode(oursCell), encode(theirsCell)) {
+	case chooseOurs:
+		m.cells = append(m.cells, *oursCell)
+		return
+	case chooseTheirs:
+		m.cells = append(m.cells, *theirsCell)
+		return
+	}
+
+	cell := *oursCell
+	var messages []string
+
+	switch choose(baseCell.Content, oursCell.Content, theirsCell.Content) {
+	case chooseTheirs:
+		cell.Content = theirsCell.Content
+	case chooseNone:
+		cell.Content = conflictContent(&oursCell.Content, &theirsCell.Content)
+		messages = append(messages, "content is changed by both sides")
+	}
+
+	switch choose(baseCell.LanguageID, oursCell.LanguageID, theirsCell.LanguageID) {
+	case chooseTheirs:
+		cell.LanguageID = theirsCell.LanguageID
+	case chooseNone:
+		messages = append(messages, "language is changed by both sides")
+	}
+
+	switch choose(encode(baseCell.Kind), encode(oursCell.Kind), encode(theirsCell.Kind)) {
+	case chooseTheirs:
+		cell.Kind = theirsCell.Kind
+	case chooseNone:
+		messages = append(messages, "kind is changed by both sides")
+	}
+
+	// outputs are produced by the runs, so ours outputs win if the both sides changed them.
+	if choose(encode(baseCell.Outputs), encode(oursCell.Outputs), encode(theirsCell.Outputs)) == chooseTheirs {
+		cell.Outputs = theirsCell.Outputs
+	}
+
+	metadata, keys := mergeMetadata(baseCell.Metadata, oursCell.Metadata, theirsCell.Metadata)
+	cell.Metadata = metadata
+	for _, key := range keys {
+		messages = append(messages, fmt.Sprintf("metadata key %q is changed by both sides", key))
+	}
+
+	if len(messages) == 0 {
+		m.cells = append(m.cells, cell)
+		return
+	}
+
+	m.addConflict(cell, strings.Join(messages, ", "))
+}
+
+func (m *merger) addConflict(cell types.NotebookCellData, message string) {
+	m.cells = append(m.cells, cell)
+	m.conflicts = append(m.conflicts, Conflict{
+		Cell:    len(m.cells),
+		Message: message,
+	})
+}
+
+// conflictCell returns the cell changed by one side and deleted by the other one.
+func conflictCell(oursCell, theirsCell *types.NotebookCellData) types.NotebookCellData {
+	var cell types.NotebookCellData
+	var oursContent, theirsContent *string
+	if oursCell != nil {
+		cell, oursContent = *oursCell, &oursCell.Content
+	}
+	if theirsCell != nil {
+		cell, theirsContent = *theirsCell, &theirsCell.Content
+	}
+	cell.Content = conflictContent(oursContent, theirsContent)
+
+	return cell
+}
+
+// conflictContent returns the content with the conflict markers, nil content means the cell is deleted.
+func conflictContent(ours, theirs *string) string {
+	oursMarker, theirsMarker := MarkerOurs, MarkerTheirs
+	if ours == nil {
+		oursMarker += deletedSuffix
+	}
+	if theirs == nil {
+		theirsMarker += deletedSuffix
+	}
+
+	lines := appendContent([]string{oursMarker}, ours)
+	lines = appendContent(append(lines, MarkerSep), theirs)
+
+	return strings.Join(append(lines, theirsMarker), "\n")
+}
+
+func appendContent(lines []string, content *string) []string {
+	if content == nil || *content == "" {
+		return lines
+	}
+
+	return append(lines, strings.TrimSuffix(*content, "\n"))
+}
+
+// mergeMetadata merges the metadata key by key and returns the keys changed by the both sides.
+func mergeMetadata(base, ours, theirs map[string]interface{}) (map[string]interface{}, []string) {
+	keys := make(map[string]struct{}, len(ours)+len(theirs))
+	for _, meta := range []map[string]interface{}{base, ours, theirs} {
+		for key := range meta {
+			keys[key] = struct{}{}
+		}
+	}
+
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	var (
+		merged    map[string]interface{}
+		conflicts []string
+	)
+	for _, key := range sortedKeys {
+		source := ours
+		switch choose(encodeKey(base, key), encodeKey(ours, key), encodeKey(theirs, key)) {
+		case chooseTheirs:
+			source = theirs
+		case chooseNone:
+			conflicts = append(conflicts, key)
+		}
+
+		if value, ok := source[key]; ok {
+			if merged == nil {
+				merged = make(map[string]interface{}, len(keys))
+			}
+			merged[key] = value
+		}
+	}
+
+	return merged, conflicts
+}
+
+type choice int
+
+const (
+	chooseOurs choice = iota
+	chooseTheirs
+	chooseNone
+)
+
+// choose returns the side that holds the merged value of the encoded values
+// or chooseNone if the both sides changed it differently.
+func choose(base, ours, theirs string) choice {
+	switch {
+	case ours == theirs, base == theirs:
+		return chooseOurs
+	case base == ours:
+		return chooseTheirs
+	}
+
+	return chooseNone
+}
+
+// encode returns JSON representation of the value to compare it with the others.
+func encode(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(data)
+}
+
+// encodeKey returns JSON representation of the metadata value or an empty string if the key is absent.
+func encodeKey(meta map[string]interface{}, key string) string {
+	value, ok := meta[key]
+	if !ok {
+		return ""
+	}
+
+	return encode(value)
+}
+
+func equal(a, b *types.NotebookCellData) bool {
+	return encode(a) == encode(b)
+}
+
+func indexOfCell(cells []types.NotebookCellData, cell *types.NotebookCellData) int {
+	for i := range cells {
+		if equal(&cells[i], cell) {
+			return i
+		}
+	}
+
+	return -1
+}
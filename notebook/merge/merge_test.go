@@ -0,0 +1,106 @@
+package merge
+
+import (
+	"testing"
+
+	"github.com/MonkeyBuisness/celli/notebook/types"
+	"github.com/stretchr/testify/require"
+)
+
+func markup(content string) types.NotebookCellData {
+	return types.NotebookCellData{
+		LanguageID: types.MarkdownLanguageID,
+		Content:    content,
+		Kind:       types.NotebookCellKindMarkup,
+	}
+}
+
+func contents(notebook *types.NotebookData) []string {
+	result := make([]string, len(notebook.Cells))
+	for i := range notebook.Cells {
+		result[i] = notebook.Cells[i].Content
+	}
+
+	return result
+}
+
+func TestNotebooks(t *testing.T) {
+	base := &types.NotebookData{
+		Metadata: map[string]interface{}{"title": "Loops", "level": "easy"},
+		Cells: []types.NotebookCellData{
+			markup("# Intro to the loops"),
+			markup("## For loop with the counter"),
+			markup("## While loop with the condition"),
+			markup("## Summary of the course"),
+		},
+	}
+
+	t.Run("changes of the both sides", func(t *testing.T) {
+		ours := &types.NotebookData{
+			Metadata: map[string]interface{}{"title": "Loops in Java", "level": "easy"},
+			Cells: []types.NotebookCellData{
+				markup("# Intro to the loops"),
+				markup("## For loop with the counter and the step"),
+				markup("## While loop with the condition"),
+				markup("## Summary of the course"),
+			},
+		}
+		theirs := &types.NotebookData{
+			Metadata: map[string]interface{}{"title": "Loops", "level": "medium", "author": "theirs"},
+			Cells: []types.NotebookCellData{
+				markup("# Intro to the loops"),
+				markup("## For loop with the counter"),
+				markup("## Do-while loop"),
+				markup("## Summary of the course"),
+			},
+		}
+
+		merged, conflicts := Notebooks(base, ours, theirs)
+		require.Empty(t, conflicts)
+		require.Equal(t, map[string]interface{}{
+			"title":  "Loops in Java",
+			"level":  "medium",
+			"author": "theirs",
+		}, merged.Metadata)
+		require.Equal(t, []string{
+			"# Intro to the loops",
+			"## For loop with the counter and the step",
+			"## Do-while loop",
+			"## Summary of the course",
+		}, contents(merged))
+	})
+
+	t.Run("conflicts", func(t *testing.T) {
+		ours := &types.NotebookData{
+			Metadata: map[string]interface{}{"title": "Ours", "level": "easy"},
+			Cells: []types.NotebookCellData{
+				markup("# Intro to the loops (ours)"),
+				markup("## For loop with the counter"),
+				markup("## While loop with the condition"),
+			},
+		}
+		theirs := &types.NotebookData{
+			Metadata: map[string]interface{}{"title": "Theirs", "level": "easy"},
+			Cells: []types.NotebookCellData{
+				markup("# Intro to the loops (theirs)"),
+				markup("## For loop with the counter"),
+				markup("## While loop with the condition"),
+				markup("## Summary of the course, updated"),
+			},
+		}
+
+		merged, conflicts := Notebooks(base, ours, theirs)
+		require.Equal(t, []Conflict{
+			{Message: `notebook metadata key "title" is changed by both sides`},
+			{Cell: 1, Message: "content is changed by both sides"},
+			{Cell: 4, Message: "cell is deleted by ours and changed by theirs"},
+		}, conflicts)
+		require.Equal(t, "Ours", merged.Metadata["title"])
+		require.Equal(t, []string{
+			"<<<<<<< ours\n# Intro to the loops (ours)\n=======\n# Intro to the loops (theirs)\n>>>>>>> theirs",
+			"## For loop with the counter",
+			"## While loop with the condition",
+			"<<<<<<< ours (deleted)\n=======\n## Summary of the course, updated\n>>>>>>> theirs",
+		}, contents(merged))
+	})
+}
@@ -0,0 +1,96 @@
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	configFileName    = "plugins.json"
+	userConfigDirName = "celli"
+	projectConfigDir  = ".celli"
+)
+
+// UserConfigPath returns the path of the plugin config file of the user.
+func UserConfigPath() (string, bool) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", false
+	}
+
+	return filepath.Join(configDir, userConfigDirName, configFileName), true
+}
+
+// ProjectConfigPath returns the path of the plugin config file of the project in the directory.
+func ProjectConfigPath(dir string) string {
+	return filepath.Join(dir, projectConfigDir, configFileName)
+}
+
+// ReadConfig reads plugin configs keyed by the comment key from the files,
+// the configs of the later files override the former ones.
+//
+// Missing files are skipped. Relative command paths are resolved against the directory of the file.
+func ReadConfig(paths ...string) (map[string]Config, error) {
+	configs := make(map[string]Config)
+	for _, path := range paths {
+		data, err := os.ReadFile(filepath.Clean(path))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var fileConfigs map[string]Config
+		if err := json.Unmarshal(data, &fileConfigs); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+
+		for key, cfg := range fileConfigs {
+			cfg.Command = ResolveCommand(cfg.Command, filepath.Dir(path))
+			configs[key] = cfg
+		}
+	}
+
+	return configs, nil
+}
+
+// ResolveCommand returns the command path joined with the directory if it is a relative path.
+//
+// Commands without a path separator are looked up in PATH, so they are returned as is.
+func ResolveCommand(command, dir string) string {
+	if !strings.ContainsAny(command, `/\`) || filepath.IsAbs(command) {
+		return command
+	}
+
+	if absDir, err := filepath.Abs(dir); err == nil {
+		dir = absDir
+	}
+
+	return filepath.Join(dir, command)
+}
+
+// Load creates plugins sorted by the key from the configs.
+func Load(configs map[string]Config) ([]*Plugin, error) {
+	keys := make([]string, 0, len(configs))
+	for key := range configs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	plugins := make([]*Plugin, len(keys))
+	for i, key := range keys {
+		p, err := New(key, configs[key])
+		if err != nil {
+			return nil, err
+		}
+		plugins[i] = p
+	}
+
+	return plugins, nil
+}
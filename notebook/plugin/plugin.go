@@ -0,0 +1,150 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/MonkeyBuisness/celli/notebook/types"
+)
+
+// DefaultTimeout is the default timeout of the plugin call.
+const DefaultTimeout = 30 * time.Second
+
+// Config represents plugin configuration model.
+type Config struct {
+	Command string   `json:"command" yaml:"command"`
+	Args    []string `json:"args,omitempty" yaml:"args,omitempty"`
+	Timeout string   `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// Project reports whether the plugin is declared by the project files,
+	// such plugins run only if the project is trusted.
+	Project bool `json:"-" yaml:"-"`
+}
+
+// Plugin represents comment serializer implemented by the external executable.
+//
+// The executable gets the Request as JSON on stdin and writes the Response as JSON to stdout.
+type Plugin struct {
+	key     string
+	command string
+	args    []string
+	timeout time.Duration
+}
+
+// Request represents the data passed to the plugin.
+type Request struct {
+	Key        string              `json:"key"`
+	Payload    string              `json:"payload"`
+	SourceName string              `json:"sourceName,omitempty"`
+	Notebook   *types.NotebookData `json:"notebook"`
+}
+
+// Response represents the data returned by the plugin.
+//
+// Cells are appended to the notebook, metadata is merged into the notebook metadata.
+type Response struct {
+	Cells    []types.NotebookCellData `json:"cells,omitempty"`
+	Metadata map[string]interface{}   `json:"metadata,omitempty"`
+	Error    string                   `json:"error,omitempty"`
+}
+
+// New returns new Plugin instance.
+func New(key string, cfg Config) (*Plugin, error) {
+	if key == "" {
+		return nil, errors.New("plugin key is not provided")
+	}
+
+	if cfg.Command == "" {
+		return nil, fmt.Errorf("plugin %s: command is not provided", key)
+	}
+
+	timeout := DefaultTimeout
+	if cfg.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(cfg.Timeout); err != nil {
+			return nil, fmt.Errorf("plugin %s: invalid timeout: %v", key, err)
+		}
+	}
+
+	return &Plugin{
+		key:     key,
+		command: cfg.Command,
+		args:    cfg.Args,
+		timeout: timeout,
+	}, nil
+}
+
+// Key returns the name of the serializable comment key.
+func (p *Plugin) Key() string {
+	return p.key
+}
+
+// Render renders serializer data to the notebook.
+func (p *Plugin) Render(notebook *types.NotebookData, payload []byte) error {
+	return p.RenderContext(&types.CommentContext{}, notebook, payload)
+}
+
+// RenderContext calls the plugin and renders its response to the notebook.
+func (p *Plugin) RenderContext(
+	ctx *types.CommentContext, notebook *types.NotebookData, payload []byte) error {
+	request, err := json.Marshal(Request{
+		Key:        p.key,
+		Payload:    string(payload),
+		SourceName: ctx.SourceName,
+		Notebook:   notebook,
+	})
+	if err != nil {
+		return err
+	}
+
+	output, err := p.call(request)
+	if err != nil {
+		return err
+	}
+
+	var response Response
+	if err := json.Unmarshal(output, &response); err != nil {
+		return fmt.Errorf("plugin %s: invalid response: %v", p.key, err)
+	}
+
+	if response.Error != "" {
+		return fmt.Errorf("plugin %s: %s", p.key, response.Error)
+	}
+
+	notebook.Cells = append(notebook.Cells, response.Cells...)
+	for key, value := range response.Metadata {
+		notebook.Metadata[key] = value
+	}
+
+	return nil
+}
+
+func (p *Plugin) call(request []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.command, p.args...) // #nosec G204
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("plugin %s: timed out after %s", p.key, p.timeout)
+		}
+
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("plugin %s: %v: %s", p.key, err, msg)
+		}
+
+		return nil, fmt.Errorf("plugin %s: %v", p.key, err)
+	}
+
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,112 @@
+package plugin
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	e "github.com/MonkeyBuisness/celli/notebook/errors"
+	"github.com/MonkeyBuisness/celli/notebook/serializer"
+	"github.com/MonkeyBuisness/celli/notebook/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newShellPlugin(t *testing.T, key, script string) *Plugin {
+	t.Helper()
+
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	p, err := New(key, Config{
+		Command: "sh",
+		Args:    []string{"-c", script},
+	})
+	require.NoError(t, err)
+
+	return p
+}
+
+func TestPlugin_Render(t *testing.T) {
+	t.Run("all ok", func(t *testing.T) {
+		// the plugin echoes the request back as the markup cell.
+		p := newShellPlugin(t, "echo", `printf '{"cells": [{"languageId": "markdown", "kind": 1, "content": %s}], `+
+			`"metadata": {"echo": true}}' "$(cat | sed 's/\\/\\\\/g; s/"/\\"/g; s/^/"/; s/$/"/')"`)
+
+		s := serializer.New()
+		notebook, err := s.SerializeNotebook(strings.NewReader("# Title\n<!-- echo:{\"a\": 1} -->"),
+			serializer.WithSourceName("book.md"),
+			serializer.WithCommentSerializer(p),
+		)
+		require.NoError(t, err)
+		require.Len(t, notebook.Cells, 2)
+		require.Equal(t, map[string]interface{}{"echo": true}, notebook.Metadata)
+		require.JSONEq(t, `{
+			"key": "echo",
+			"payload": "{\"a\": 1}",
+			"sourceName": "book.md",
+			"notebook": {"cells": [{"languageId": "markdown", "kind": 1, "content": "# Title"}]}
+		}`, notebook.Cells[1].Content)
+	})
+	t.Run("plugin error", func(t *testing.T) {
+		p := newShellPlugin(t, "fail", `echo '{"error": "unsupported diagram"}'`)
+
+		s := serializer.New()
+		_, err := s.SerializeNotebook(strings.NewReader("<!-- fail:{} -->"),
+			serializer.WithCommentSerializer(p),
+		)
+		require.ErrorIs(t, err, e.ErrRenderNotebook)
+		require.Contains(t, err.Error(), "1:1: plugin fail: unsupported diagram")
+	})
+	t.Run("exit status", func(t *testing.T) {
+		p := newShellPlugin(t, "crash", `echo 'boom' >&2; exit 2`)
+
+		s := serializer.New()
+		diagnostics, err := s.Validate(strings.NewReader("<!-- crash: -->"),
+			serializer.WithCommentSerializer(p),
+		)
+		require.NoError(t, err)
+		require.Len(t, diagnostics, 1)
+		require.Equal(t, serializer.DiagnosticRenderError, diagnostics[0].Code)
+		require.Equal(t, "plugin crash: exit status 2: boom", diagnostics[0].Message)
+	})
+}
+
+func Test_ReadConfig(t *testing.T) {
+	userPath, projectPath := filepath.Join(t.TempDir(), "plugins.json"), filepath.Join(t.TempDir(), "plugins.json")
+	require.NoError(t, os.WriteFile(userPath, []byte(`{
+		"mermaid": {"command": "celli-mermaid"},
+		"sql": {"command": "celli-sql", "timeout": "5s"}
+	}`), 0o600))
+	require.NoError(t, os.WriteFile(projectPath, []byte(`{
+		"mermaid": {"command": "./tools/mermaid", "args": ["--svg"]}
+	}`), 0o600))
+
+	configs, err := ReadConfig(userPath, projectPath, filepath.Join(t.TempDir(), "missing.json"))
+	require.NoError(t, err)
+	require.Equal(t, map[string]Config{
+		"mermaid": {Command: filepath.Join(filepath.Dir(projectPath), "tools", "mermaid"), Args: []string{"--svg"}},
+		"sql":     {Command: "celli-sql", Timeout: "5s"},
+	}, configs)
+
+	plugins, err := Load(configs)
+	require.NoError(t, err)
+	require.Len(t, plugins, 2)
+	require.Equal(t, "mermaid", plugins[0].Key())
+	require.Equal(t, "sql", plugins[1].Key())
+
+	_, err = Load(map[string]Config{"sql": {}})
+	require.EqualError(t, err, "plugin sql: command is not provided")
+}
+
+func Test_New(t *testing.T) {
+	_, err := New("sql", Config{Command: "celli-sql", Timeout: "soon"})
+	require.EqualError(t, err, `plugin sql: invalid timeout: time: invalid duration "soon"`)
+
+	p, err := New("sql", Config{Command: "celli-sql"})
+	require.NoError(t, err)
+	require.Equal(t, DefaultTimeout, p.timeout)
+	require.Implements(t, (*types.ContextualComment)(nil), p)
+}
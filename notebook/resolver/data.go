@@ -0,0 +1,45 @@
+package resolver
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+const (
+	dataPrefix       = SchemeData + ":"
+	dataBase64Suffix = ";base64"
+)
+
+// DataResolver represents resolver of the data: URIs (RFC 2397).
+type DataResolver struct{}
+
+// NewDataResolver returns new DataResolver instance.
+func NewDataResolver() DataResolver {
+	return DataResolver{}
+}
+
+// Resolve decodes the content of the data: URI.
+func (r DataResolver) Resolve(uri string) ([]byte, error) {
+	if !strings.HasPrefix(strings.ToLower(uri), dataPrefix) {
+		return nil, errors.New("not a data URI")
+	}
+
+	index := strings.IndexByte(uri, ',')
+	if index == -1 {
+		return nil, errors.New("malformed data URI: missing comma")
+	}
+	mediaType, data := uri[len(dataPrefix):index], uri[index+1:]
+
+	if strings.HasSuffix(strings.ToLower(mediaType), dataBase64Suffix) {
+		return base64.StdEncoding.DecodeString(data)
+	}
+
+	content, err := url.PathUnescape(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(content), nil
+}
@@ -0,0 +1,68 @@
+package resolver
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MonkeyBuisness/celli/notebook/types"
+)
+
+const filePrefix = SchemeFile + "://"
+
+// FileResolver represents resolver of the local files.
+type FileResolver struct {
+	baseDir string
+}
+
+// NewFileResolver returns new FileResolver instance.
+//
+// Relative paths are resolved relative to the baseDir (or to the working directory if it is empty).
+func NewFileResolver(baseDir string) FileResolver {
+	return FileResolver{
+		baseDir: baseDir,
+	}
+}
+
+// Resolve reads the content of the file:// URI or of the file path.
+func (r FileResolver) Resolve(uri string) ([]byte, error) {
+	filePath, _ := FilePath(uri)
+	if !filepath.IsAbs(filePath) && r.baseDir != "" {
+		filePath = filepath.Join(r.baseDir, filePath)
+	}
+
+	return os.ReadFile(filepath.Clean(filePath))
+}
+
+// BaseDirResolver represents URI resolver that resolves relative file paths against the base directory
+// and delegates resolving to the base resolver.
+type BaseDirResolver struct {
+	base    types.URIResolver
+	baseDir string
+}
+
+// NewBaseDirResolver returns new BaseDirResolver instance.
+func NewBaseDirResolver(base types.URIResolver, baseDir string) BaseDirResolver {
+	return BaseDirResolver{
+		base:    base,
+		baseDir: baseDir,
+	}
+}
+
+// Resolve reads the content of the URI, relative file paths are joined with the base directory.
+func (r BaseDirResolver) Resolve(uri string) ([]byte, error) {
+	if filePath, ok := FilePath(uri); ok && !filepath.IsAbs(filePath) {
+		uri = filepath.Join(r.baseDir, filePath)
+	}
+
+	return r.base.Resolve(uri)
+}
+
+// FilePath returns the local path of the file:// URI or of the URI without a scheme.
+func FilePath(uri string) (string, bool) {
+	if scheme := Scheme(uri); scheme != "" && scheme != SchemeFile {
+		return "", false
+	}
+
+	return filepath.FromSlash(strings.TrimPrefix(uri, filePrefix)), true
+}
@@ -0,0 +1,69 @@
+package resolver
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/MonkeyBuisness/celli/notebook/utils"
+)
+
+// HTTP resolver defaults.
+const (
+	DefaultHTTPTimeout       = 30 * time.Second
+	DefaultHTTPMaxSize int64 = 10 << 20
+)
+
+// HTTPResolver represents resolver of the remote HTTP(S) files.
+type HTTPResolver struct {
+	client  *http.Client
+	maxSize int64
+}
+
+// NewHTTPResolver returns new HTTPResolver instance.
+//
+// Requests are cancelled after the timeout and responses larger than maxSize bytes are rejected.
+func NewHTTPResolver(timeout time.Duration, maxSize int64) HTTPResolver {
+	return HTTPResolver{
+		client: &http.Client{
+			Timeout: timeout,
+		},
+		maxSize: maxSize,
+	}
+}
+
+// Resolve downloads the content of the URI.
+func (r HTTPResolver) Resolve(uri string) ([]byte, error) {
+	resp, err := r.client.Get(uri)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.Close(resp.Body)
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+
+	if r.maxSize > 0 && resp.ContentLength > r.maxSize {
+		return nil, fmt.Errorf("content size %d exceeds the limit of %d bytes", resp.ContentLength, r.maxSize)
+	}
+
+	var (
+		buf    bytes.Buffer
+		reader io.Reader = resp.Body
+	)
+	if r.maxSize > 0 {
+		reader = io.LimitReader(resp.Body, r.maxSize+1)
+	}
+	if _, err := buf.ReadFrom(reader); err != nil {
+		return nil, err
+	}
+
+	if r.maxSize > 0 && int64(buf.Len()) > r.maxSize {
+		return nil, fmt.Errorf("content size exceeds the limit of %d bytes", r.maxSize)
+	}
+
+	return buf.Bytes(), nil
+}
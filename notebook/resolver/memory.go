@@ -0,0 +1,45 @@
+package resolver
+
+import (
+	"fmt"
+	"sync"
+)
+
+// MemoryResolver represents in-memory resolver, mostly useful in tests.
+type MemoryResolver struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+// NewMemoryResolver returns new MemoryResolver instance with the content keyed by the full URI.
+func NewMemoryResolver(files map[string][]byte) *MemoryResolver {
+	r := &MemoryResolver{
+		files: make(map[string][]byte, len(files)),
+	}
+	for uri, content := range files {
+		r.files[uri] = content
+	}
+
+	return r
+}
+
+// Set sets the content of the URI.
+func (r *MemoryResolver) Set(uri string, content []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.files[uri] = content
+}
+
+// Resolve returns the content of the URI.
+func (r *MemoryResolver) Resolve(uri string) ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	content, ok := r.files[uri]
+	if !ok {
+		return nil, fmt.Errorf("%s: not found", uri)
+	}
+
+	return content, nil
+}
@@ -0,0 +1,83 @@
+package resolver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MonkeyBuisness/celli/notebook/types"
+)
+
+// URI scheme.
+const (
+	SchemeFile  = "file"
+	SchemeHTTP  = "http"
+	SchemeHTTPS = "https"
+	SchemeData  = "data"
+	SchemeMem   = "mem"
+)
+
+// Registry represents URI resolver that delegates resolving to the resolver registered for the URI scheme.
+type Registry struct {
+	resolvers map[string]types.URIResolver
+}
+
+// NewRegistry returns new empty Registry instance.
+func NewRegistry() *Registry {
+	return &Registry{
+		resolvers: make(map[string]types.URIResolver),
+	}
+}
+
+// Default returns registry with the built-in file, HTTP(S) and data resolvers.
+func Default() *Registry {
+	httpResolver := NewHTTPResolver(DefaultHTTPTimeout, DefaultHTTPMaxSize)
+
+	return NewRegistry().
+		Register(SchemeFile, NewFileResolver("")).
+		Register(SchemeHTTP, httpResolver).
+		Register(SchemeHTTPS, httpResolver).
+		Register(SchemeData, NewDataResolver())
+}
+
+// Register registers resolver for the URI scheme.
+func (r *Registry) Register(scheme string, resolver types.URIResolver) *Registry {
+	r.resolvers[strings.ToLower(scheme)] = resolver
+
+	return r
+}
+
+// Resolve reads the content of the URI with the resolver registered for its scheme.
+//
+// URI without a scheme is resolved as a file path.
+func (r *Registry) Resolve(uri string) ([]byte, error) {
+	scheme := Scheme(uri)
+	if scheme == "" {
+		scheme = SchemeFile
+	}
+
+	resolver, ok := r.resolvers[scheme]
+	if !ok {
+		return nil, fmt.Errorf("unsupported URI scheme %q", scheme)
+	}
+
+	return resolver.Resolve(uri)
+}
+
+// Scheme returns the lower-cased scheme of the URI or an empty string if it is not provided.
+func Scheme(uri string) string {
+	index := strings.IndexByte(uri, ':')
+	// single letter schemes are most likely Windows drive letters.
+	if index < 2 {
+		return ""
+	}
+
+	for i, r := range uri[:index] {
+		isLetter := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		isSchemeChar := (r >= '0' && r <= '9') || r == '+' || r == '-' || r == '.'
+		if !isLetter && (i == 0 || !isSchemeChar) {
+			return ""
+		}
+	}
+
+	return strings.ToLower(uri[:index])
+}
@@ -0,0 +1,140 @@
+package resolver
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Scheme(t *testing.T) {
+	for uri, scheme := range map[string]string{
+		"file://Main.java":           SchemeFile,
+		"HTTPS://example.com/a.java": SchemeHTTPS,
+		"data:,hello":                SchemeData,
+		"Main.java":                  "",
+		"C:\\src\\Main.java":         "",
+		"./dir:name/Main.java":       "",
+	} {
+		require.Equal(t, scheme, Scheme(uri), uri)
+	}
+}
+
+func TestRegistry_Resolve(t *testing.T) {
+	t.Run("unsupported scheme", func(t *testing.T) {
+		_, err := NewRegistry().Resolve("ftp://example.com/Main.java")
+		require.EqualError(t, err, `unsupported URI scheme "ftp"`)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		r := NewRegistry().Register(SchemeMem, NewMemoryResolver(map[string][]byte{
+			"mem://Main.java": []byte("class Main {}"),
+		}))
+
+		data, err := r.Resolve("mem://Main.java")
+		require.NoError(t, err)
+		require.Equal(t, "class Main {}", string(data))
+
+		_, err = r.Resolve("mem://Other.java")
+		require.EqualError(t, err, "mem://Other.java: not found")
+	})
+}
+
+func TestFileResolver_Resolve(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Main.java"), []byte("class Main {}"), 0o600))
+
+	data, err := NewFileResolver(dir).Resolve("file://Main.java")
+	require.NoError(t, err)
+	require.Equal(t, "class Main {}", string(data))
+
+	data, err = NewFileResolver("").Resolve("file://" + filepath.ToSlash(filepath.Join(dir, "Main.java")))
+	require.NoError(t, err)
+	require.Equal(t, "class Main {}", string(data))
+}
+
+func TestBaseDirResolver_Resolve(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Main.java"), []byte("class Main {}"), 0o600))
+
+	r := NewBaseDirResolver(Default(), dir)
+	for _, uri := range []string{"file://Main.java", "Main.java", "file://" + filepath.ToSlash(filepath.Join(dir, "Main.java"))} {
+		data, err := r.Resolve(uri)
+		require.NoError(t, err, uri)
+		require.Equal(t, "class Main {}", string(data))
+	}
+
+	data, err := r.Resolve("data:,text")
+	require.NoError(t, err)
+	require.Equal(t, "text", string(data))
+}
+
+func Test_FilePath(t *testing.T) {
+	path, ok := FilePath("file://src/Main.java")
+	require.True(t, ok)
+	require.Equal(t, filepath.FromSlash("src/Main.java"), path)
+
+	path, ok = FilePath("Main.java")
+	require.True(t, ok)
+	require.Equal(t, "Main.java", path)
+
+	_, ok = FilePath("https://example.com/Main.java")
+	require.False(t, ok)
+}
+
+func TestHTTPResolver_Resolve(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			fmt.Fprint(w, "class Main {}")
+		case "/large":
+			fmt.Fprint(w, strings.Repeat("a", 64))
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	r := NewHTTPResolver(100*time.Millisecond, 32)
+
+	t.Run("all ok", func(t *testing.T) {
+		data, err := r.Resolve(srv.URL + "/ok")
+		require.NoError(t, err)
+		require.Equal(t, "class Main {}", string(data))
+	})
+	t.Run("status error", func(t *testing.T) {
+		_, err := r.Resolve(srv.URL + "/missing")
+		require.EqualError(t, err, "unexpected response status: 404 Not Found")
+	})
+	t.Run("size limit", func(t *testing.T) {
+		_, err := r.Resolve(srv.URL + "/large")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "exceeds the limit of 32 bytes")
+	})
+	t.Run("timeout", func(t *testing.T) {
+		_, err := r.Resolve(srv.URL + "/slow")
+		require.Error(t, err)
+	})
+}
+
+func TestDataResolver_Resolve(t *testing.T) {
+	r := NewDataResolver()
+
+	data, err := r.Resolve("data:,class%20Main%20%7B%7D")
+	require.NoError(t, err)
+	require.Equal(t, "class Main {}", string(data))
+
+	data, err = r.Resolve("data:text/x-java;base64,Y2xhc3MgTWFpbiB7fQ==")
+	require.NoError(t, err)
+	require.Equal(t, "class Main {}", string(data))
+
+	_, err = r.Resolve("data:text/plain")
+	require.EqualError(t, err, "malformed data URI: missing comma")
+}
@@ -0,0 +1,20 @@
+//go:build windows
+// +build windows
+
+package runner
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup does nothing, process groups are not supported.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills the command, its child processes are not tracked.
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+
+	_ = cmd.Process.Kill()
+}
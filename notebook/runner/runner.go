@@ -0,0 +1,383 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MonkeyBuisness/celli/notebook/types"
+	"github.com/MonkeyBuisness/celli/notebook/utils"
+)
+
+// Output item MIME type.
+const (
+	MimeStdout = "application/vnd.code.notebook.stdout"
+	MimeStderr = "application/vnd.code.notebook.stderr"
+)
+
+// Cell metadata key.
+const (
+	MetaIsExecutable = "is-executable"
+	MetaTimeout      = "timeout"
+)
+
+// Output metadata key.
+const (
+	OutputMetaExitCode = "exitCode"
+	OutputMetaTimedOut = "timedOut"
+)
+
+const (
+	// DefaultTimeout is the default timeout of the cell execution.
+	DefaultTimeout = 30 * time.Second
+
+	// FilePlaceholder is replaced with the path to the file that contains cell content.
+	FilePlaceholder = "{file}"
+
+	cellFileName = "Main"
+)
+
+// Option represents runner option model.
+type Option func(*Options)
+
+// Options represents runner configuration model.
+type Options struct {
+	commands map[string]Command
+	timeout  time.Duration
+	selector Selector
+}
+
+// Command represents the command that runs code cells of the language.
+type Command struct {
+	Args      []string
+	Extension string
+}
+
+// Result represents the result of the cell execution.
+type Result struct {
+	// Index is the index of the cell in the notebook.
+	Index int
+	// CodeCell is the 1-based number of the code cell the selector uses.
+	CodeCell int
+	ExitCode int
+	TimedOut bool
+}
+
+// Runner represents code cells runner implementation.
+type Runner struct {
+	opts Options
+}
+
+// New returns new Runner instance.
+func New(opt ...Option) Runner {
+	opts := Options{
+		commands: DefaultCommands(),
+		timeout:  DefaultTimeout,
+	}
+	for _, o := range opt {
+		o(&opts)
+	}
+
+	return Runner{
+		opts: opts,
+	}
+}
+
+// DefaultCommands returns runner commands of the supported languages keyed by the language ID.
+func DefaultCommands() map[string]Command {
+	return map[string]Command{
+		"java": {
+			Args:      []string{"java", FilePlaceholder},
+			Extension: ".java",
+		},
+		"shellscript": {
+			Args:      []string{"sh", FilePlaceholder},
+			Extension: ".sh",
+		},
+		"sh": {
+			Args:      []string{"sh", FilePlaceholder},
+			Extension: ".sh",
+		},
+		"go": {
+			Args:      []string{"go", "run", FilePlaceholder},
+			Extension: ".go",
+		},
+		"python": {
+			Args:      []string{"python3", FilePlaceholder},
+			Extension: ".py",
+		},
+	}
+}
+
+// ParseCommand parses `lang=command {file}` runner definition.
+//
+// The extension of the known language is kept, the `{file}` placeholder is appended if it is missing.
+func ParseCommand(s string) (string, Command, error) {
+	var cmd Command
+
+	sepIndex := strings.Index(s, "=")
+	if sepIndex == -1 {
+		return "", cmd, fmt.Errorf("invalid runner %q: expected lang=command", s)
+	}
+
+	lang := strings.TrimSpace(s[:sepIndex])
+	cmd.Args = strings.Fields(s[sepIndex+1:])
+	if lang == "" || len(cmd.Args) == 0 {
+		return "", cmd, fmt.Errorf("invalid runner %q: expected lang=command", s)
+	}
+
+	hasFile := false
+	for _, arg := range cmd.Args {
+		if strings.Contains(arg, FilePlaceholder) {
+			hasFile = true
+			break
+		}
+	}
+	if !hasFile {
+		cmd.Args = append(cmd.Args, FilePlaceholder)
+	}
+
+	cmd.Extension = "." + lang
+	if defaultCmd, ok := DefaultCommands()[lang]; ok {
+		cmd.Extension = defaultCmd.Extension
+	}
+
+	return lang, cmd, nil
+}
+
+// WithCommand sets the command that runs code cells of the language.
+func WithCommand(lang string, cmd Command) Option {
+	return func(o *Options) {
+		o.commands[lang] = cmd
+	}
+}
+
+// WithTimeout sets the default timeout of the cell execution.
+//
+// It is overridden by the `timeout` cell metadata (e.g. "5s").
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.timeout = timeout
+	}
+}
+
+// WithSelector limits the execution to the selected cells.
+func WithSelector(s Selector) Option {
+	return func(o *Options) {
+		o.selector = s
+	}
+}
+
+// Run executes the code cells of the notebook and replaces their outputs with the captured ones.
+//
+// Cells with `is-executable: false` metadata, cells that are not selected
+// and cells of the languages without a runner command are left untouched.
+// The selector numbers the code cells only (markup cells are not counted).
+func (r *Runner) Run(ctx context.Context, notebook *types.NotebookData) ([]Result, error) {
+	var results []Result
+	codeCells := 0
+	for i := range notebook.Cells {
+		c := &notebook.Cells[i]
+
+		if c.Kind != types.NotebookCellKindCode {
+			continue
+		}
+		codeCells++
+
+		if !r.opts.selector.Contains(codeCells) || !isExecutable(c) {
+			continue
+		}
+
+		cmd, ok := r.opts.commands[c.LanguageID]
+		if !ok {
+			continue
+		}
+
+		timeout, err := r.cellTimeout(c)
+		if err != nil {
+			return nil, fmt.Errorf("code cell %d: %v", codeCells, err)
+		}
+
+		result, output, err := runCell(ctx, cmd, c.Content, timeout)
+		if err != nil {
+			return nil, fmt.Errorf("code cell %d: %v", codeCells, err)
+		}
+		result.Index, result.CodeCell = i, codeCells
+
+		c.Outputs = []types.NotebookCellOutput{*output}
+		results = append(results, *result)
+	}
+
+	return results, nil
+}
+
+func (r *Runner) cellTimeout(c *types.NotebookCellData) (time.Duration, error) {
+	value, ok := c.Metadata[MetaTimeout]
+	if !ok {
+		return r.opts.timeout, nil
+	}
+
+	switch v := value.(type) {
+	case string:
+		timeout, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timeout: %v", err)
+		}
+
+		return timeout, nil
+	case float64:
+		return time.Duration(v * float64(time.Second)), nil
+	}
+
+	return 0, fmt.Errorf("invalid timeout %v", value)
+}
+
+func isExecutable(c *types.NotebookCellData) bool {
+	switch v := c.Metadata[MetaIsExecutable].(type) {
+	case bool:
+		return v
+	case string:
+		executable, err := strconv.ParseBool(v)
+		return err != nil || executable
+	}
+
+	return true
+}
+
+func runCell(
+	ctx context.Context, cmd Command, content string, timeout time.Duration) (*Result, *types.NotebookCellOutput, error) {
+	dir, err := os.MkdirTemp("", "celli-run-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, cellFileName+cmd.Extension)
+	if err := os.WriteFile(file, []byte(content), types.DefaultFileMode); err != nil {
+		return nil, nil, fmt.Errorf("could not write cell content: %v", err)
+	}
+
+	args := make([]string, len(cmd.Args))
+	for i := range cmd.Args {
+		args[i] = strings.ReplaceAll(cmd.Args[i], FilePlaceholder, file)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// the outputs are captured to the files, so the killed command does not
+	// hang the runner while its child processes keep the pipes open.
+	stdoutFile, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not create output file: %v", err)
+	}
+	defer utils.Close(stdoutFile)
+
+	stderrFile, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not create output file: %v", err)
+	}
+	defer utils.Close(stderrFile)
+
+	execCmd := exec.Command(args[0], args[1:]...) // #nosec G204
+	execCmd.Dir = dir
+	execCmd.Stdout = stdoutFile
+	execCmd.Stderr = stderrFile
+	setProcessGroup(execCmd)
+
+	var result Result
+	if err := wait(ctx, execCmd); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, nil, fmt.Errorf("could not run %s: %v", args[0], err)
+		}
+		result.ExitCode = exitErr.ExitCode()
+	}
+
+	stdout, err := readOutput(stdoutFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stderr, err := readOutput(stderrFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		result.TimedOut = true
+		fmt.Fprintf(stderr, "timed out after %s\n", timeout)
+	}
+
+	output := types.NotebookCellOutput{
+		Items: make([]types.NotebookCellOutputItem, 0, 2),
+		Metadata: map[string]interface{}{
+			OutputMetaExitCode: result.ExitCode,
+		},
+	}
+	if result.TimedOut {
+		output.Metadata[OutputMetaTimedOut] = true
+	}
+	if stdout.Len() != 0 {
+		output.Items = append(output.Items, types.NotebookCellOutputItem{
+			Mime: MimeStdout,
+			Data: stdout.String(),
+		})
+	}
+	if stderr.Len() != 0 {
+		output.Items = append(output.Items, types.NotebookCellOutputItem{
+			Mime: MimeStderr,
+			Data: stderr.String(),
+		})
+	}
+
+	// output without items can not be stored in the template.
+	if len(output.Items) == 0 {
+		output.Items = append(output.Items, types.NotebookCellOutputItem{
+			Mime: MimeStdout,
+		})
+	}
+
+	return &result, &output, nil
+}
+
+// wait runs the command and kills its whole process group when the context is done,
+// so the child processes (e.g. the program built by `go run`) do not outlive the timeout.
+func wait(ctx context.Context, cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			killProcessGroup(cmd)
+		case <-done:
+		}
+	}()
+
+	return cmd.Wait()
+}
+
+func readOutput(file *os.File) (*bytes.Buffer, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("could not read output: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(file); err != nil {
+		return nil, fmt.Errorf("could not read output: %v", err)
+	}
+
+	return &buf, nil
+}
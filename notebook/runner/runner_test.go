@@ -0,0 +1,146 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/MonkeyBuisness/celli/notebook/types"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseSelector(t *testing.T) {
+	s, err := ParseSelector("1, 3-5,8-")
+	require.NoError(t, err)
+	for n, selected := range map[int]bool{
+		1: true, 2: false, 3: true, 5: true, 6: false, 8: true, 100: true,
+	} {
+		require.Equal(t, selected, s.Contains(n), n)
+	}
+
+	s, err = ParseSelector("")
+	require.NoError(t, err)
+	require.True(t, s.Contains(42))
+
+	_, err = ParseSelector("5-3")
+	require.EqualError(t, err, `invalid cell selector "5-3": expected cell number not less than 5`)
+}
+
+func Test_ParseCommand(t *testing.T) {
+	lang, cmd, err := ParseCommand("java=java --enable-preview --source 21")
+	require.NoError(t, err)
+	require.Equal(t, "java", lang)
+	require.Equal(t, Command{
+		Args:      []string{"java", "--enable-preview", "--source", "21", FilePlaceholder},
+		Extension: ".java",
+	}, cmd)
+
+	_, _, err = ParseCommand("java")
+	require.EqualError(t, err, `invalid runner "java": expected lang=command`)
+}
+
+func TestRunner_Run(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	notebook := types.NotebookData{
+		Cells: []types.NotebookCellData{
+			{LanguageID: types.MarkdownLanguageID, Kind: types.NotebookCellKindMarkup, Content: "# Title"},
+			{LanguageID: "sh", Kind: types.NotebookCellKindCode, Content: "echo hello; echo oops >&2; exit 3"},
+			{
+				LanguageID: "sh",
+				Kind:       types.NotebookCellKindCode,
+				Content:    "echo skipped",
+				Metadata:   map[string]interface{}{MetaIsExecutable: "false"},
+			},
+			{
+				LanguageID: "sh",
+				Kind:       types.NotebookCellKindCode,
+				Content:    "sleep 5",
+				Metadata:   map[string]interface{}{MetaTimeout: "100ms"},
+			},
+			{LanguageID: "unknown", Kind: types.NotebookCellKindCode, Content: "skipped"},
+		},
+	}
+
+	r := New(WithTimeout(time.Minute))
+	results, err := r.Run(context.Background(), &notebook)
+	require.NoError(t, err)
+	require.Equal(t, []Result{
+		{Index: 1, CodeCell: 1, ExitCode: 3},
+		{Index: 3, CodeCell: 3, ExitCode: -1, TimedOut: true},
+	}, results)
+
+	require.Equal(t, []types.NotebookCellOutput{
+		{
+			Items: []types.NotebookCellOutputItem{
+				{Mime: MimeStdout, Data: "hello\n"},
+				{Mime: MimeStderr, Data: "oops\n"},
+			},
+			Metadata: map[string]interface{}{OutputMetaExitCode: 3},
+		},
+	}, notebook.Cells[1].Outputs)
+	require.Empty(t, notebook.Cells[2].Outputs)
+	require.Equal(t, true, notebook.Cells[3].Outputs[0].Metadata[OutputMetaTimedOut])
+	require.Empty(t, notebook.Cells[4].Outputs)
+}
+
+func TestRunner_Run_Selector(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	notebook := types.NotebookData{
+		Cells: []types.NotebookCellData{
+			{LanguageID: types.MarkdownLanguageID, Kind: types.NotebookCellKindMarkup, Content: "# Title"},
+			{LanguageID: "sh", Kind: types.NotebookCellKindCode, Content: "echo 1"},
+			{LanguageID: types.MarkdownLanguageID, Kind: types.NotebookCellKindMarkup, Content: "text"},
+			{LanguageID: "sh", Kind: types.NotebookCellKindCode, Content: "echo 2"},
+		},
+	}
+
+	selector, err := ParseSelector("2")
+	require.NoError(t, err)
+
+	// the second code cell is selected, markup cells are not counted.
+	r := New(WithSelector(selector))
+	results, err := r.Run(context.Background(), &notebook)
+	require.NoError(t, err)
+	require.Equal(t, []Result{{Index: 3, CodeCell: 2}}, results)
+	require.Empty(t, notebook.Cells[1].Outputs)
+	require.Equal(t, "2\n", notebook.Cells[3].Outputs[0].Items[0].Data)
+}
+
+func TestRunner_Run_KillsChildren(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("process groups are not supported")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	marker := filepath.Join(t.TempDir(), "marker")
+	notebook := types.NotebookData{
+		Cells: []types.NotebookCellData{
+			{
+				LanguageID: "sh",
+				Kind:       types.NotebookCellKindCode,
+				Content:    fmt.Sprintf("sh -c 'sleep 1; touch %s' &\nwait", marker),
+				Metadata:   map[string]interface{}{MetaTimeout: "100ms"},
+			},
+		},
+	}
+
+	r := New()
+	results, err := r.Run(context.Background(), &notebook)
+	require.NoError(t, err)
+	require.True(t, results[0].TimedOut)
+
+	time.Sleep(1500 * time.Millisecond)
+	require.NoFileExists(t, marker)
+}
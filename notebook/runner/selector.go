@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Selector represents a set of 1-based cell numbers.
+//
+// Empty selector contains all the cells.
+type Selector struct {
+	ranges []cellRange
+}
+
+type cellRange struct {
+	from int
+	to   int
+}
+
+// ParseSelector parses comma separated cell numbers and ranges like `1,3-5,8-`.
+func ParseSelector(s string) (Selector, error) {
+	var selector Selector
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		r, err := parseCellRange(part)
+		if err != nil {
+			return Selector{}, fmt.Errorf("invalid cell selector %q: %v", part, err)
+		}
+		selector.ranges = append(selector.ranges, r)
+	}
+
+	return selector, nil
+}
+
+// Contains reports whether the cell number is selected.
+func (s Selector) Contains(n int) bool {
+	if len(s.ranges) == 0 {
+		return true
+	}
+
+	for _, r := range s.ranges {
+		if n >= r.from && (r.to == 0 || n <= r.to) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseCellRange(s string) (cellRange, error) {
+	from, to := s, s
+	if sepIndex := strings.Index(s, "-"); sepIndex != -1 {
+		from, to = s[:sepIndex], s[sepIndex+1:]
+	}
+
+	var (
+		r   cellRange
+		err error
+	)
+	if from == "" {
+		r.from = 1
+	} else if r.from, err = strconv.Atoi(from); err != nil || r.from < 1 {
+		return r, fmt.Errorf("expected positive cell number")
+	}
+
+	if to != "" {
+		if r.to, err = strconv.Atoi(to); err != nil || r.to < r.from {
+			return r, fmt.Errorf("expected cell number not less than %d", r.from)
+		}
+	}
+
+	return r, nil
+}
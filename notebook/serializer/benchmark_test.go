@@ -0,0 +1,69 @@
+package serializer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/MonkeyBuisness/celli/notebook/serializer/comments"
+)
+
+var benchmarkDocSizes = []int{
+	64 << 10,
+	512 << 10,
+	2 << 20,
+	5 << 20,
+}
+
+// generateDocument generates template of the specified size (in bytes)
+// with serializable comments spread evenly across the text.
+func generateDocument(size int) string {
+	const chunk = "# Chapter\n\nJava is a high-level, class-based, object-oriented programming language.\n\n" +
+		"<!-- br: -->\n\n" +
+		"<!-- code:{\"lang\": \"java\", \"content\": \"class Main {}\"} -->\n\n" +
+		"<!-- just an html comment -->\n\n"
+
+	var sb strings.Builder
+	sb.Grow(size + len(chunk))
+	for sb.Len() < size {
+		sb.WriteString(chunk)
+	}
+
+	return sb.String()
+}
+
+func Benchmark_tokenizeDocument(b *testing.B) {
+	for _, size := range benchmarkDocSizes {
+		doc := generateDocument(size)
+
+		b.Run(fmt.Sprintf("%dKB", size>>10), func(b *testing.B) {
+			b.SetBytes(int64(len(doc)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				tokenizeDocument(doc, false)
+			}
+		})
+	}
+}
+
+func BenchmarkSerializer_SerializeNotebook(b *testing.B) {
+	for _, size := range benchmarkDocSizes {
+		doc := generateDocument(size)
+
+		b.Run(fmt.Sprintf("%dKB", size>>10), func(b *testing.B) {
+			s := New()
+			b.SetBytes(int64(len(doc)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := s.SerializeNotebook(strings.NewReader(doc),
+					WithCommentSerializer(
+						comments.NewBrCommentSerializer(),
+						comments.NewCodeCommentSerializer(),
+					),
+				); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -4,17 +4,10 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"net/http"
-	"os"
-	"path/filepath"
-	"strings"
 
+	"github.com/MonkeyBuisness/celli/notebook/lock"
+	"github.com/MonkeyBuisness/celli/notebook/resolver"
 	"github.com/MonkeyBuisness/celli/notebook/types"
-	"github.com/MonkeyBuisness/celli/notebook/utils"
-)
-
-const (
-	filePrefix = "file://"
 )
 
 // CodeCommentSerializer represents <!-- code:{...} --> comment serializer.
@@ -25,6 +18,9 @@ type codeCommentPayload struct {
 	Meta       map[string]interface{} `json:"meta,omitempty"`
 	Content    string                 `json:"content,omitempty"`
 	URI        string                 `json:"uri,omitempty"`
+	Lines      string                 `json:"lines,omitempty"`
+	Region     string                 `json:"region,omitempty"`
+	SHA256     string                 `json:"sha256,omitempty"`
 }
 
 // NewCodeCommentSerializer returns new CodeCommentSerializer instance.
@@ -39,64 +35,102 @@ func (s CodeCommentSerializer) Key() string {
 
 // Render renders serializer data to the notebook.
 func (s CodeCommentSerializer) Render(notebook *types.NotebookData, payload []byte) error {
+	return s.RenderContext(defaultCommentContext(), notebook, payload)
+}
+
+// RenderContext renders serializer data to the notebook within the template context.
+func (s CodeCommentSerializer) RenderContext(
+	ctx *types.CommentContext, notebook *types.NotebookData, payload []byte) error {
 	var code codeCommentPayload
 	if err := json.Unmarshal(payload, &code); err != nil {
 		return err
 	}
 
 	if code.URI != "" {
-		content, err := readURIContent(code.URI)
+		content, err := ctx.Resolver.Resolve(code.URI)
 		if err != nil {
 			return fmt.Errorf("could not read URI content: %v", err)
 		}
+
+		if code.SHA256 != "" {
+			if err := lock.Verify(content, code.SHA256); err != nil {
+				return fmt.Errorf("%s: %v", code.URI, err)
+			}
+		}
 		code.Content = string(content)
 	}
 
-	notebook.Cells = append(notebook.Cells, types.NotebookCellData{
+	content, err := extractCode(code.Content, code.Lines, code.Region)
+	if err != nil {
+		return err
+	}
+	code.Content = content
+
+	cell := types.NotebookCellData{
 		LanguageID: code.LanguageID,
 		Content:    code.Content,
 		Kind:       types.NotebookCellKindCode,
 		Metadata:   code.Meta,
-	})
+	}
+	src := types.CellSource{
+		Comment: s.Key(),
+		URI:     code.URI,
+		Lines:   code.Lines,
+		Region:  code.Region,
+		SHA256:  code.SHA256,
+		Style:   types.CellSourceStyleJSON,
+	}
+	if code.URI != "" {
+		src.Checksum = lock.Hash([]byte(code.Content))
+	}
+	cell.SetSource(src)
+	notebook.Cells = append(notebook.Cells, cell)
 
 	return nil
 }
 
-func readURIContent(uri string) ([]byte, error) {
-	isFile := strings.HasPrefix(uri, filePrefix)
-	if isFile {
-		filePath := strings.TrimPrefix(uri, filePrefix)
-
-		data, err := os.ReadFile(filepath.Clean(filePath))
-		if err != nil {
-			return nil, err
-		}
-
-		return data, nil
+// uriContent reports whether the cell content still equals to the content loaded from the source uri.
+//
+// Cells without the recorded checksum are compared with the sha256 pin of the whole URI content,
+// the others are considered edited, so their content is never lost.
+func uriContent(cell *types.NotebookCellData, src types.CellSource) bool {
+	switch {
+	case src.URI == "":
+		return false
+	case src.Checksum != "":
+		return lock.Verify([]byte(cell.Content), src.Checksum) == nil
+	case src.SHA256 != "" && src.Lines == "" && src.Region == "":
+		return lock.Verify([]byte(cell.Content), src.SHA256) == nil
 	}
 
-	//nolint:gosec,gocritic,bodyclose // itэs entirely the responsibility of the author.
-	resp, err := http.Get(uri)
-	if err != nil {
-		return nil, err
-	}
-	defer utils.Close(resp.Body)
+	return false
+}
 
-	var buf bytes.Buffer
-	if _, err := buf.ReadFrom(resp.Body); err != nil {
-		return nil, err
+func defaultCommentContext() *types.CommentContext {
+	return &types.CommentContext{
+		Resolver: resolver.Default(),
 	}
-
-	return buf.Bytes(), nil
 }
 
 // NewCode creates new <!-- code:{} --> comment string.
-func NewCode(cell *types.NotebookCellData) ([]byte, error) {
-	data, err := json.Marshal(codeCommentPayload{
+//
+// If the cell content is loaded from the source uri and is not edited since then,
+// the uri is used instead of the content.
+func NewCode(cell *types.NotebookCellData, src types.CellSource) ([]byte, error) {
+	payload := codeCommentPayload{
 		LanguageID: cell.LanguageID,
-		Meta:       cell.Metadata,
+		Meta:       cell.UserMetadata(),
 		Content:    cell.Content,
-	})
+	}
+	if uriContent(cell, src) {
+		payload.Content = ""
+		payload.URI = src.URI
+		payload.Lines = src.Lines
+		payload.Region = src.Region
+		payload.SHA256 = src.SHA256
+	}
+
+	data, err := json.Marshal(payload)
 	if err != nil {
 		return nil, err
 	}
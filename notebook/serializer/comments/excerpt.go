@@ -0,0 +1,178 @@
+package comments
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	regionStartMarker = "region:"
+	regionEndMarker   = "endregion"
+	linesRangeSep     = "-"
+)
+
+// regionCommentPrefixes contains line comment tokens the region markers can be written with.
+var regionCommentPrefixes = []string{"//", "#", "--", ";", "/*", "<!--"}
+
+// extractCode returns the part of the code selected by the lines range or by the region name.
+// The result is dedented.
+func extractCode(content, lines, region string) (string, error) {
+	if lines == "" && region == "" {
+		return content, nil
+	}
+
+	if lines != "" && region != "" {
+		return "", errors.New("lines and region can not be used together")
+	}
+
+	codeLines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+
+	var (
+		selected []string
+		err      error
+	)
+	if lines != "" {
+		selected, err = selectLines(codeLines, lines)
+	} else {
+		selected, err = selectRegion(codeLines, region)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join(dedent(selected), "\n"), nil
+}
+
+// selectLines selects lines by the 1-based inclusive range like `12-30`, `12-`, `-30` or `12`.
+func selectLines(codeLines []string, lines string) ([]string, error) {
+	from, to := 1, len(codeLines)
+
+	bounds := strings.SplitN(lines, linesRangeSep, 2)
+	parseBound := func(bound string, value *int) error {
+		bound = strings.TrimSpace(bound)
+		if bound == "" {
+			return nil
+		}
+
+		n, err := strconv.Atoi(bound)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid lines range %q", lines)
+		}
+		*value = n
+
+		return nil
+	}
+
+	if err := parseBound(bounds[0], &from); err != nil {
+		return nil, err
+	}
+	if len(bounds) == 1 {
+		to = from
+	} else if err := parseBound(bounds[1], &to); err != nil {
+		return nil, err
+	}
+
+	if from > to || to > len(codeLines) {
+		return nil, fmt.Errorf("lines range %q is out of the code bounds (1-%d)", lines, len(codeLines))
+	}
+
+	return codeLines[from-1 : to], nil
+}
+
+// selectRegion selects lines between `// region:name` and the matching `// endregion` markers.
+// Markers of the nested regions are removed.
+func selectRegion(codeLines []string, region string) ([]string, error) {
+	start := -1
+	for i := range codeLines {
+		if name, ok := regionMarker(codeLines[i]); ok && name == region {
+			start = i
+			break
+		}
+	}
+	if start == -1 {
+		return nil, fmt.Errorf("region %q is not found", region)
+	}
+
+	var (
+		selected []string
+		depth    = 1
+	)
+	for _, line := range codeLines[start+1:] {
+		name, ok := regionMarker(line)
+		switch {
+		case ok && name == regionEndMarker:
+			depth--
+			if depth == 0 {
+				return selected, nil
+			}
+			continue
+		case ok:
+			depth++
+			continue
+		}
+
+		selected = append(selected, line)
+	}
+
+	return nil, fmt.Errorf("region %q is not closed with %q", region, regionEndMarker)
+}
+
+// regionMarker returns region name of the start marker or `endregion` for the end marker.
+func regionMarker(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+
+	var ok bool
+	for _, prefix := range regionCommentPrefixes {
+		if strings.HasPrefix(line, prefix) {
+			line, ok = strings.TrimSpace(strings.TrimPrefix(line, prefix)), true
+			break
+		}
+	}
+	if !ok {
+		return "", false
+	}
+	line = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(line, "*/"), "-->"))
+
+	if line == regionEndMarker {
+		return regionEndMarker, true
+	}
+
+	if strings.HasPrefix(line, regionStartMarker) {
+		return strings.TrimSpace(strings.TrimPrefix(line, regionStartMarker)), true
+	}
+
+	return "", false
+}
+
+// dedent removes the common leading whitespace of the non-blank lines.
+func dedent(lines []string) []string {
+	var prefix string
+	first := true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			prefix, first = indent, false
+			continue
+		}
+
+		for !strings.HasPrefix(indent, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	dedented := make([]string, len(lines))
+	for i, line := range lines {
+		dedented[i] = strings.TrimPrefix(line, prefix)
+		if strings.TrimSpace(dedented[i]) == "" {
+			dedented[i] = ""
+		}
+	}
+
+	return dedented
+}
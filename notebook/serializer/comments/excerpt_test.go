@@ -0,0 +1,70 @@
+package comments
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testExcerptCode = `package main;
+
+public class Main {
+    // region:main
+    public static void main(String[] args) {
+        // region:print
+        System.out.println("Hello");
+        // endregion
+    }
+    // endregion
+}
+`
+
+func Test_extractCode(t *testing.T) {
+	t.Run("no selection", func(t *testing.T) {
+		code, err := extractCode(testExcerptCode, "", "")
+		require.NoError(t, err)
+		require.Equal(t, testExcerptCode, code)
+	})
+	t.Run("lines and region", func(t *testing.T) {
+		_, err := extractCode(testExcerptCode, "1-2", "main")
+		require.EqualError(t, err, "lines and region can not be used together")
+	})
+	t.Run("lines", func(t *testing.T) {
+		code, err := extractCode(testExcerptCode, "5-9", "")
+		require.NoError(t, err)
+		require.Equal(t, "public static void main(String[] args) {\n    // region:print\n"+
+			"    System.out.println(\"Hello\");\n    // endregion\n}", code)
+
+		code, err = extractCode(testExcerptCode, "11", "")
+		require.NoError(t, err)
+		require.Equal(t, "}", code)
+
+		code, err = extractCode(testExcerptCode, "-1", "")
+		require.NoError(t, err)
+		require.Equal(t, "package main;", code)
+	})
+	t.Run("invalid lines", func(t *testing.T) {
+		_, err := extractCode(testExcerptCode, "a-b", "")
+		require.EqualError(t, err, `invalid lines range "a-b"`)
+
+		_, err = extractCode(testExcerptCode, "10-20", "")
+		require.EqualError(t, err, `lines range "10-20" is out of the code bounds (1-11)`)
+	})
+	t.Run("region", func(t *testing.T) {
+		code, err := extractCode(testExcerptCode, "", "main")
+		require.NoError(t, err)
+		require.Equal(t, "public static void main(String[] args) {\n"+
+			"    System.out.println(\"Hello\");\n}", code)
+
+		code, err = extractCode(testExcerptCode, "", "print")
+		require.NoError(t, err)
+		require.Equal(t, "System.out.println(\"Hello\");", code)
+	})
+	t.Run("missing region", func(t *testing.T) {
+		_, err := extractCode(testExcerptCode, "", "missing")
+		require.EqualError(t, err, `region "missing" is not found`)
+
+		_, err = extractCode("// region:open\ncode", "", "open")
+		require.EqualError(t, err, `region "open" is not closed with "endregion"`)
+	})
+}
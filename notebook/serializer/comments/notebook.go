@@ -3,7 +3,6 @@ package comments
 import (
 	"encoding/json"
 	"fmt"
-	"strings"
 
 	"github.com/MonkeyBuisness/celli/notebook/types"
 )
@@ -36,13 +35,17 @@ func (s NotebookCommentSerializer) Render(notebook *types.NotebookData, payload
 }
 
 // NewNotebook creates new <!-- notebook:{} --> comment string.
-func NewNotebook(meta map[string]interface{}) string {
-	var metaFields string
-	for key, value := range meta {
-		metaFields = fmt.Sprintf("%s\t%q: \"%v\",\n", metaFields, key, value)
+//
+// Metadata values are encoded as JSON, so their types survive the round trip.
+func NewNotebook(meta map[string]interface{}) ([]byte, error) {
+	if meta == nil {
+		meta = make(map[string]interface{})
+	}
+
+	data, err := json.MarshalIndent(meta, "", "\t")
+	if err != nil {
+		return nil, err
 	}
-	metaFields = strings.TrimSuffix(metaFields, "\n")
-	metaFields = metaFields[:len(metaFields)-1]
 
-	return fmt.Sprintf("<!-- notebook:{\n%s\n} -->", metaFields)
+	return []byte(fmt.Sprintf("<!-- notebook:%s -->", data)), nil
 }
@@ -0,0 +1,65 @@
+package comments
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/MonkeyBuisness/celli/notebook/types"
+)
+
+// OutputCommentSerializer represents <!-- output:{...} --> comment serializer.
+type OutputCommentSerializer struct{}
+
+// NewOutputCommentSerializer returns new OutputCommentSerializer instance.
+func NewOutputCommentSerializer() OutputCommentSerializer {
+	return OutputCommentSerializer{}
+}
+
+// Key returns the name of the serializable comment key.
+func (s OutputCommentSerializer) Key() string {
+	return "output"
+}
+
+// Render attaches the output to the preceding code cell.
+func (s OutputCommentSerializer) Render(notebook *types.NotebookData, payload []byte) error {
+	var output types.NotebookCellOutput
+	if err := json.Unmarshal(payload, &output); err != nil {
+		return err
+	}
+
+	if len(output.Items) == 0 {
+		return errors.New("output does not contain any items")
+	}
+
+	for i := range output.Items {
+		if output.Items[i].Mime == "" {
+			return fmt.Errorf("output item %d: mime type is not provided", i)
+		}
+	}
+
+	if len(notebook.Cells) == 0 || notebook.Cells[len(notebook.Cells)-1].Kind != types.NotebookCellKindCode {
+		return errors.New("output comment must follow the code cell")
+	}
+
+	cell := &notebook.Cells[len(notebook.Cells)-1]
+	cell.Outputs = append(cell.Outputs, output)
+
+	return nil
+}
+
+// NewOutput creates new <!-- output:{} --> comment string.
+func NewOutput(output *types.NotebookCellOutput) ([]byte, error) {
+	data, err := json.Marshal(output)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "\t"); err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf("<!-- output:%s -->", buf.String())), nil
+}
@@ -1,12 +1,23 @@
 package comments
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
+	"github.com/MonkeyBuisness/celli/notebook/lock"
 	"github.com/MonkeyBuisness/celli/notebook/types"
 	"gopkg.in/yaml.v2"
 )
 
+const (
+	ycodeIndent = "    "
+
+	commentCloseTag = "-->"
+)
+
 // YCodeCommentSerializer represents <!-- ycode:{...} --> comment serializer.
 type YCodeCommentSerializer struct{}
 
@@ -14,6 +25,9 @@ type ycodeCommentPayload struct {
 	LanguageID string                 `yaml:"lang"`
 	Content    string                 `yaml:"code,omitempty,flow"`
 	URI        string                 `yaml:"uri,omitempty"`
+	Lines      string                 `yaml:"lines,omitempty"`
+	Region     string                 `yaml:"region,omitempty"`
+	SHA256     string                 `yaml:"sha256,omitempty"`
 	Meta       map[string]interface{} `yaml:"meta,omitempty"`
 }
 
@@ -29,25 +43,160 @@ func (s YCodeCommentSerializer) Key() string {
 
 // Render renders serializer data to the notebook.
 func (s YCodeCommentSerializer) Render(notebook *types.NotebookData, payload []byte) error {
+	return s.RenderContext(defaultCommentContext(), notebook, payload)
+}
+
+// RenderContext renders serializer data to the notebook within the template context.
+func (s YCodeCommentSerializer) RenderContext(
+	ctx *types.CommentContext, notebook *types.NotebookData, payload []byte) error {
+	if len(payload) < 2 {
+		return errors.New("empty ycode payload")
+	}
+
 	var code ycodeCommentPayload
 	if err := yaml.Unmarshal(payload[1:len(payload)-1], &code); err != nil {
 		return err
 	}
 
 	if code.URI != "" {
-		content, err := readURIContent(code.URI)
+		content, err := ctx.Resolver.Resolve(code.URI)
 		if err != nil {
 			return fmt.Errorf("could not read URI content: %v", err)
 		}
+
+		if code.SHA256 != "" {
+			if err := lock.Verify(content, code.SHA256); err != nil {
+				return fmt.Errorf("%s: %v", code.URI, err)
+			}
+		}
 		code.Content = string(content)
 	}
 
-	notebook.Cells = append(notebook.Cells, types.NotebookCellData{
+	content, err := extractCode(code.Content, code.Lines, code.Region)
+	if err != nil {
+		return err
+	}
+	code.Content = content
+
+	cell := types.NotebookCellData{
 		LanguageID: code.LanguageID,
 		Content:    code.Content,
 		Kind:       types.NotebookCellKindCode,
 		Metadata:   code.Meta,
-	})
+	}
+	src := types.CellSource{
+		Comment: s.Key(),
+		URI:     code.URI,
+		Lines:   code.Lines,
+		Region:  code.Region,
+		SHA256:  code.SHA256,
+		Style:   types.CellSourceStyleYAML,
+	}
+	if code.URI != "" {
+		src.Checksum = lock.Hash([]byte(code.Content))
+	}
+	cell.SetSource(src)
+	notebook.Cells = append(notebook.Cells, cell)
 
 	return nil
 }
+
+// NewYCode creates new <!-- ycode:{} --> comment string.
+//
+// If the cell content is loaded from the source uri and is not edited since then,
+// the uri is used instead of the content.
+// YAML can not escape the comment close tag, so the cells containing it are created as <!-- code:{} --> comments.
+func NewYCode(cell *types.NotebookCellData, src types.CellSource) ([]byte, error) {
+	data, err := newYCode(cell, src)
+	if err != nil {
+		return nil, err
+	}
+
+	body := data[:len(data)-len(commentCloseTag)]
+	if bytes.Contains(body, []byte(commentCloseTag)) {
+		return NewCode(cell, src)
+	}
+
+	return data, nil
+}
+
+func newYCode(cell *types.NotebookCellData, src types.CellSource) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("<!-- ycode:{\n")
+
+	header := ycodeCommentPayload{
+		LanguageID: cell.LanguageID,
+	}
+	fromURI := uriContent(cell, src)
+	if fromURI {
+		header.URI = src.URI
+		header.Lines = src.Lines
+		header.Region = src.Region
+		header.SHA256 = src.SHA256
+	}
+
+	headerData, err := yaml.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	writeIndented(&buf, string(headerData), ycodeIndent)
+
+	if !fromURI {
+		writeYAMLLiteral(&buf, "code", cell.Content)
+	}
+
+	if meta := cell.UserMetadata(); len(meta) != 0 {
+		data, err := yaml.Marshal(map[string]interface{}{
+			"meta": meta,
+		})
+		if err != nil {
+			return nil, err
+		}
+		writeIndented(&buf, string(data), ycodeIndent)
+	}
+
+	buf.WriteString("} -->")
+
+	return buf.Bytes(), nil
+}
+
+// writeYAMLLiteral writes multiline string as a YAML literal block scalar,
+// so the code stays readable (tabs are not escaped).
+func writeYAMLLiteral(buf *bytes.Buffer, key, value string) {
+	header := "|"
+	if hasIndentedLine(value) {
+		header += strconv.Itoa(len(ycodeIndent))
+	}
+
+	switch trimmed := strings.TrimRight(value, "\n"); {
+	case len(value)-len(trimmed) == 0:
+		header += "-"
+	case len(value)-len(trimmed) > 1:
+		header += "+"
+	}
+
+	fmt.Fprintf(buf, "%s%s: %s\n", ycodeIndent, key, header)
+	writeIndented(buf, strings.TrimSuffix(value, "\n"), ycodeIndent+ycodeIndent)
+}
+
+// hasIndentedLine reports whether any non-empty line starts with whitespace,
+// so the literal block needs the explicit indentation indicator.
+func hasIndentedLine(value string) bool {
+	for _, line := range strings.Split(value, "\n") {
+		if line != "" && (line[0] == ' ' || line[0] == '\t') {
+			return true
+		}
+	}
+
+	return false
+}
+
+func writeIndented(buf *bytes.Buffer, text, indent string) {
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		if line != "" {
+			buf.WriteString(indent)
+			buf.WriteString(line)
+		}
+		buf.WriteString("\n")
+	}
+}
@@ -0,0 +1,76 @@
+package serializer
+
+import (
+	"fmt"
+	"strings"
+
+	e "github.com/MonkeyBuisness/celli/notebook/errors"
+)
+
+// Diagnostic severity.
+const (
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Diagnostic code.
+const (
+	DiagnosticUnknownKey          = "unknown-key"
+	DiagnosticUnterminatedComment = "unterminated-comment"
+	DiagnosticInvalidPayload      = "invalid-payload"
+	DiagnosticRenderError         = "render-error"
+	DiagnosticEmptyCodeCell       = "empty-code-cell"
+)
+
+// Severity represents diagnostic severity.
+type Severity string
+
+// Diagnostic represents a problem found in the template source.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"`
+	Position Position `json:"position"`
+	Message  string   `json:"message"`
+	Excerpt  string   `json:"excerpt,omitempty"`
+}
+
+// DiagnosticError represents an error that contains all diagnostics collected during serialization.
+type DiagnosticError struct {
+	Diagnostics []Diagnostic
+}
+
+func newDiagnostic(src *source, severity Severity, code string, offset int, msg string) Diagnostic {
+	return Diagnostic{
+		Severity: severity,
+		Code:     code,
+		Position: src.position(offset),
+		Message:  msg,
+		Excerpt:  src.excerpt(offset),
+	}
+}
+
+// String returns human-readable diagnostic message with the source excerpt.
+func (d Diagnostic) String() string {
+	if d.Excerpt == "" {
+		return fmt.Sprintf("%s: %s", d.Position, d.Message)
+	}
+
+	return fmt.Sprintf("%s: %s\n%s", d.Position, d.Message, d.Excerpt)
+}
+
+// Error returns human-readable error message.
+func (err *DiagnosticError) Error() string {
+	messages := make([]string, len(err.Diagnostics))
+	for i := range err.Diagnostics {
+		d := &err.Diagnostics[i]
+		messages[i] = fmt.Sprintf("%s: %s", d.Severity, d)
+	}
+
+	return fmt.Sprintf("%v: %d problem(s) found\n%s",
+		e.ErrInvalidTemplate, len(err.Diagnostics), strings.Join(messages, "\n"))
+}
+
+// Unwrap returns the base error.
+func (err *DiagnosticError) Unwrap() error {
+	return e.ErrInvalidTemplate
+}
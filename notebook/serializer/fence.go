@@ -0,0 +1,277 @@
+package serializer
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/MonkeyBuisness/celli/notebook/types"
+)
+
+const (
+	fenceMinLength   = 3
+	fenceAttrMeta    = "meta"
+	fenceMetaSep     = ","
+	fenceMetaKVSep   = "="
+	fenceBacktick    = '`'
+	fenceTilde       = '~'
+	fenceAttrsOpen   = "{"
+	fenceAttrsClose  = "}"
+	fenceIndentLimit = 3
+)
+
+var fenceAttrRegexp = regexp.MustCompile(`([\w-]+)\s*=\s*"([^"]*)"`)
+
+type codeNode struct {
+	*baseNode
+
+	languageID string
+	content    string
+	meta       map[string]interface{}
+}
+
+type fenceLine struct {
+	char   byte
+	length int
+	info   string
+}
+
+func (n codeNode) render(notebook *types.NotebookData) error {
+	cell := types.NotebookCellData{
+		LanguageID: n.languageID,
+		Content:    n.content,
+		Kind:       types.NotebookCellKindCode,
+		Metadata:   n.meta,
+	}
+	cell.SetSource(types.CellSource{
+		Style: types.CellSourceStyleFence,
+	})
+	notebook.Cells = append(notebook.Cells, cell)
+
+	return nil
+}
+
+// splitFencedCode splits text nodes into markup and code nodes
+// according to the fenced code blocks found inside the text.
+func splitFencedCode(nodes []documentNode) []documentNode {
+	splitNodes := make([]documentNode, 0, len(nodes))
+
+	for i := range nodes {
+		tNode, ok := nodes[i].(textNode)
+		if !ok {
+			splitNodes = append(splitNodes, nodes[i])
+			continue
+		}
+
+		splitNodes = append(splitNodes, splitTextNode(tNode)...)
+	}
+
+	return splitNodes
+}
+
+func splitTextNode(n textNode) []documentNode {
+	var (
+		nodes     []documentNode
+		textStart int
+		offset    int
+		open      *fenceLine
+		openStart int
+		bodyStart int
+	)
+
+	content := n.content
+	for offset < len(content) {
+		lineEnd := strings.IndexByte(content[offset:], '\n')
+		next := len(content)
+		if lineEnd != -1 {
+			next = offset + lineEnd + 1
+		}
+		line := strings.TrimRight(content[offset:next], "\r\n")
+
+		if open == nil {
+			if fence, ok := parseFenceLine(line); ok {
+				open = &fence
+				openStart = offset
+				bodyStart = next
+			}
+			offset = next
+			continue
+		}
+
+		if !isClosingFence(line, open) {
+			offset = next
+			continue
+		}
+
+		// fenced blocks without language stay the part of the markup.
+		languageID, meta := parseFenceInfo(open.info)
+		if languageID != "" {
+			if textStart < openStart {
+				nodes = append(nodes, newTextNode(
+					n.start+textStart, n.start+openStart, content[textStart:openStart]))
+			}
+			nodes = append(nodes, codeNode{
+				baseNode: &baseNode{
+					start: n.start + openStart,
+					end:   n.start + next,
+					kind:  nodeKindCode,
+				},
+				languageID: languageID,
+				content:    strings.TrimSuffix(content[bodyStart:offset], "\n"),
+				meta:       meta,
+			})
+			textStart = next
+		}
+		open = nil
+		offset = next
+	}
+
+	if textStart == 0 {
+		return []documentNode{n}
+	}
+
+	if textStart < len(content) {
+		nodes = append(nodes, newTextNode(n.start+textStart, n.end, content[textStart:]))
+	}
+
+	return nodes
+}
+
+func parseFenceLine(line string) (fenceLine, bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > fenceIndentLimit || trimmed == "" {
+		return fenceLine{}, false
+	}
+
+	char := trimmed[0]
+	if char != fenceBacktick && char != fenceTilde {
+		return fenceLine{}, false
+	}
+
+	length := 0
+	for length < len(trimmed) && trimmed[length] == char {
+		length++
+	}
+	if length < fenceMinLength {
+		return fenceLine{}, false
+	}
+
+	info := strings.TrimSpace(trimmed[length:])
+	// backtick fences can not contain backticks in the info string.
+	if char == fenceBacktick && strings.IndexByte(info, fenceBacktick) != -1 {
+		return fenceLine{}, false
+	}
+
+	return fenceLine{
+		char:   char,
+		length: length,
+		info:   info,
+	}, true
+}
+
+func isClosingFence(line string, open *fenceLine) bool {
+	fence, ok := parseFenceLine(line)
+
+	return ok && fence.char == open.char && fence.length >= open.length && fence.info == ""
+}
+
+// parseFenceInfo parses fence info string like `java {meta="is-executable=false"}`.
+func parseFenceInfo(info string) (languageID string, meta map[string]interface{}) {
+	attrsStart := strings.Index(info, fenceAttrsOpen)
+	languageID = info
+	if attrsStart != -1 {
+		languageID = info[:attrsStart]
+	}
+	if fields := strings.Fields(languageID); len(fields) != 0 {
+		languageID = fields[0]
+	}
+
+	attrsEnd := strings.LastIndex(info, fenceAttrsClose)
+	if attrsStart == -1 || attrsEnd < attrsStart {
+		return languageID, nil
+	}
+
+	for _, attr := range fenceAttrRegexp.FindAllStringSubmatch(info[attrsStart:attrsEnd], -1) {
+		if attr[1] != fenceAttrMeta {
+			continue
+		}
+
+		for _, pair := range strings.Split(attr[2], fenceMetaSep) {
+			kv := strings.SplitN(pair, fenceMetaKVSep, 2)
+			key := strings.TrimSpace(kv[0])
+			if key == "" {
+				continue
+			}
+
+			if meta == nil {
+				meta = make(map[string]interface{})
+			}
+
+			var value string
+			if len(kv) == 2 {
+				value = strings.TrimSpace(kv[1])
+			}
+			meta[key] = parseFenceMetaValue(value)
+		}
+	}
+
+	return languageID, meta
+}
+
+// parseFenceMetaValue parses boolean and number meta values the way they are written by the converter,
+// any other value is kept as a string.
+func parseFenceMetaValue(text string) interface{} {
+	var value interface{}
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return text
+	}
+
+	switch value.(type) {
+	case bool, float64:
+		return value
+	default:
+		return text
+	}
+}
+
+// fencedBlockEnd reports whether the comment starting at commStart is placed inside a fenced code block
+// opened in the text after textStart and returns the end of the block.
+func fencedBlockEnd(content string, textStart, commStart int) (int, bool) {
+	offset := textStart
+	// fences are only opened at the beginning of the line.
+	if offset > 0 && content[offset-1] != '\n' {
+		lineEnd := strings.IndexByte(content[offset:], '\n')
+		if lineEnd == -1 {
+			return 0, false
+		}
+		offset += lineEnd + 1
+	}
+
+	var open *fenceLine
+	for offset < len(content) {
+		lineEnd := strings.IndexByte(content[offset:], '\n')
+		next := len(content)
+		if lineEnd != -1 {
+			next = offset + lineEnd + 1
+		}
+		line := strings.TrimRight(content[offset:next], "\r\n")
+
+		if open == nil {
+			if offset >= commStart {
+				return 0, false
+			}
+			if fence, ok := parseFenceLine(line); ok {
+				open = &fence
+			}
+		} else if isClosingFence(line, open) {
+			if next > commStart {
+				return next, true
+			}
+			open = nil
+		}
+		offset = next
+	}
+
+	// unclosed fences stay the part of the markup.
+	return 0, false
+}
@@ -0,0 +1,117 @@
+package serializer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/MonkeyBuisness/celli/notebook/types"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	frontMatterDelimiter    = "---"
+	frontMatterEndDelimiter = "..."
+)
+
+// frontMatterNode represents YAML front matter block at the top of the template.
+//
+// It is always the first node, so the <!-- notebook:{} --> comments override its keys.
+type frontMatterNode struct {
+	*baseNode
+
+	payload      string
+	payloadStart int
+}
+
+// parseFrontMatter returns front matter node if the content starts with the `---` delimited YAML block.
+func parseFrontMatter(content string) (*frontMatterNode, bool) {
+	firstLineEnd := strings.IndexByte(content, '\n')
+	if firstLineEnd == -1 || strings.TrimRight(content[:firstLineEnd], "\r") != frontMatterDelimiter {
+		return nil, false
+	}
+
+	payloadStart := firstLineEnd + 1
+	for lineStart := payloadStart; lineStart < len(content); {
+		lineEnd := strings.IndexByte(content[lineStart:], '\n')
+		if lineEnd == -1 {
+			lineEnd = len(content)
+		} else {
+			lineEnd += lineStart
+		}
+
+		switch strings.TrimRight(content[lineStart:lineEnd], "\r") {
+		case frontMatterDelimiter, frontMatterEndDelimiter:
+			end := lineEnd
+			if end < len(content) {
+				end++
+			}
+
+			return &frontMatterNode{
+				baseNode: &baseNode{
+					start: 0,
+					end:   end,
+					kind:  nodeKindFrontMatter,
+				},
+				payload:      content[payloadStart:lineStart],
+				payloadStart: payloadStart,
+			}, true
+		}
+
+		lineStart = lineEnd + 1
+	}
+
+	// front matter without the closing delimiter is a regular markup.
+	return nil, false
+}
+
+func (n *frontMatterNode) render(notebook *types.NotebookData) error {
+	var meta interface{}
+	if err := yaml.Unmarshal([]byte(n.payload), &meta); err != nil {
+		return err
+	}
+
+	if meta == nil {
+		return nil
+	}
+
+	values, ok := normalizeYAML(meta).(map[string]interface{})
+	if !ok {
+		return errors.New("front matter must be a YAML mapping")
+	}
+
+	for key, value := range values {
+		notebook.Metadata[key] = value
+	}
+
+	return nil
+}
+
+// describeError returns the document offset and the diagnostic code of the render error.
+func (n *frontMatterNode) describeError(src *source, err error) (offset int, code string) {
+	if offset, ok := yamlErrorOffset(src, n.payloadStart, err); ok {
+		return offset, DiagnosticInvalidPayload
+	}
+
+	return n.start, DiagnosticInvalidPayload
+}
+
+// normalizeYAML converts YAML mappings to the map[string]interface{},
+// so the values can be encoded as JSON.
+func normalizeYAML(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = normalizeYAML(value)
+		}
+
+		return m
+	case []interface{}:
+		for i := range v {
+			v[i] = normalizeYAML(v[i])
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,125 @@
+package serializer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MonkeyBuisness/celli/notebook/types"
+	"github.com/MonkeyBuisness/celli/notebook/utils"
+)
+
+const (
+	includeKey        = "include"
+	includeFilePrefix = "file://"
+)
+
+// includeComment represents <!-- include:{...} --> comment serializer.
+//
+// It is bound to the template being serialized, so the included templates
+// are resolved relative to it and parsed with the same options.
+type includeComment struct {
+	serializer *Serializer
+	opts       *Options
+}
+
+type includeCommentPayload struct {
+	URI string `json:"uri"`
+}
+
+// Key returns the name of the serializable comment key.
+func (c *includeComment) Key() string {
+	return includeKey
+}
+
+// Render parses the included template in place.
+func (c *includeComment) Render(notebook *types.NotebookData, payload []byte) error {
+	var include includeCommentPayload
+	if err := json.Unmarshal(payload, &include); err != nil {
+		return err
+	}
+
+	if include.URI == "" {
+		return errors.New("include uri is not provided")
+	}
+
+	includePath := c.resolvePath(include.URI)
+	if c.opts.observer != nil {
+		c.opts.observer(includePath)
+	}
+
+	for _, p := range c.opts.includeChain {
+		if p == includePath {
+			chain := append(append([]string{}, c.opts.includeChain...), includePath)
+			for i := range chain {
+				chain[i] = displayPath(chain[i])
+			}
+
+			return fmt.Errorf("include cycle detected: %s", strings.Join(chain, " -> "))
+		}
+	}
+
+	file, err := os.Open(filepath.Clean(includePath))
+	if err != nil {
+		return fmt.Errorf("could not open included template: %v", err)
+	}
+	defer utils.Close(file)
+
+	// included template is parsed with the same options.
+	opts := *c.opts
+	opts.sourceName = displayPath(includePath)
+	opts.includeChain = append(append([]string{}, c.opts.includeChain...), includePath)
+	opts.serializers = make(map[string]types.SerializableComment, len(c.opts.serializers))
+	for key, s := range c.opts.serializers {
+		opts.serializers[key] = s
+	}
+
+	included, diagnostics, err := c.serializer.serialize(file, &opts)
+	if err != nil {
+		return err
+	}
+
+	if len(diagnostics) != 0 {
+		return &DiagnosticError{
+			Diagnostics: diagnostics,
+		}
+	}
+
+	notebook.Cells = append(notebook.Cells, included.Cells...)
+	for key, value := range included.Metadata {
+		notebook.Metadata[key] = value
+	}
+
+	return nil
+}
+
+// resolvePath resolves include URI relative to the including template.
+func (c *includeComment) resolvePath(uri string) string {
+	includePath := filepath.FromSlash(strings.TrimPrefix(uri, includeFilePrefix))
+	if !filepath.IsAbs(includePath) {
+		includePath = filepath.Join(filepath.Dir(c.opts.sourceName), includePath)
+	}
+
+	if absPath, err := filepath.Abs(includePath); err == nil {
+		includePath = absPath
+	}
+
+	return includePath
+}
+
+// displayPath returns path relative to the working directory if it is possible.
+func displayPath(p string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return p
+	}
+
+	if relPath, err := filepath.Rel(wd, p); err == nil && !strings.HasPrefix(relPath, "..") {
+		return relPath
+	}
+
+	return p
+}
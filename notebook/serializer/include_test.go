@@ -0,0 +1,117 @@
+package serializer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MonkeyBuisness/celli/notebook/internal/testutil"
+	"github.com/MonkeyBuisness/celli/notebook/serializer/comments"
+	"github.com/stretchr/testify/require"
+)
+
+func serializeFile(t *testing.T, path string, opt ...Option) error {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	s := New()
+	_, err = s.SerializeNotebook(file, append([]Option{
+		WithSourceName(path),
+		WithIncludes(),
+		WithCommentSerializer(comments.NewBrCommentSerializer()),
+	}, opt...)...)
+
+	return err
+}
+
+func TestSerializer_SerializeNotebook_Include(t *testing.T) {
+	t.Run("all ok", func(t *testing.T) {
+		dir := testutil.WriteFiles(t, map[string]string{
+			"book.md":        "# Book\n<!-- include:{\"uri\": \"file://chapters/01.md\"} -->\n# End",
+			"chapters/01.md": "# Chapter 1\n<!-- include:{\"uri\": \"file://02.md\"} -->",
+			"chapters/02.md": "# Chapter 2<!-- br: -->text",
+		})
+
+		file, err := os.Open(filepath.Join(dir, "book.md"))
+		require.NoError(t, err)
+		defer file.Close()
+
+		s := New()
+		notebook, err := s.SerializeNotebook(file,
+			WithSourceName(filepath.Join(dir, "book.md")),
+			WithIncludes(),
+			WithCommentSerializer(comments.NewBrCommentSerializer()),
+		)
+		require.NoError(t, err)
+
+		contents := make([]string, len(notebook.Cells))
+		for i := range notebook.Cells {
+			contents[i] = notebook.Cells[i].Content
+		}
+		require.Equal(t, []string{"# Book", "# Chapter 1", "# Chapter 2", "text", "# End"}, contents)
+	})
+	t.Run("file uris relative to the included template", func(t *testing.T) {
+		dir := testutil.WriteFiles(t, map[string]string{
+			"book.md":            "<!-- include:{\"uri\": \"file://chapters/01.md\"} -->",
+			"chapters/01.md":     "<!-- code:{\"lang\": \"java\", \"uri\": \"file://Main.java\"} -->",
+			"chapters/Main.java": "class Main {}",
+		})
+
+		file, err := os.Open(filepath.Join(dir, "book.md"))
+		require.NoError(t, err)
+		defer file.Close()
+
+		s := New()
+		notebook, err := s.SerializeNotebook(file,
+			WithSourceName(filepath.Join(dir, "book.md")),
+			WithIncludes(),
+			WithCommentSerializer(comments.NewCodeCommentSerializer()),
+		)
+		require.NoError(t, err)
+		require.Len(t, notebook.Cells, 1)
+		require.Equal(t, "class Main {}", notebook.Cells[0].Content)
+	})
+	t.Run("diagnostics in encounter order", func(t *testing.T) {
+		dir := testutil.WriteFiles(t, map[string]string{
+			"z.md": "<!-- unknown:{} -->\n<!-- include:{\"uri\": \"file://a.md\"} -->\n<!-- other:{} -->",
+			"a.md": "<!-- chapter:{} -->",
+		})
+
+		err := serializeFile(t, filepath.Join(dir, "z.md"), WithStrict())
+
+		var diagErr *DiagnosticError
+		require.ErrorAs(t, err, &diagErr)
+		require.Len(t, diagErr.Diagnostics, 3)
+		for i, key := range []string{"unknown", "chapter", "other"} {
+			require.Equal(t, "could not serialize comment: unknown key "+key, diagErr.Diagnostics[i].Message)
+		}
+	})
+	t.Run("cycle", func(t *testing.T) {
+		dir := testutil.WriteFiles(t, map[string]string{
+			"a.md": "<!-- include:{\"uri\": \"file://b.md\"} -->",
+			"b.md": "<!-- include:{\"uri\": \"file://a.md\"} -->",
+		})
+
+		err := serializeFile(t, filepath.Join(dir, "a.md"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "include cycle detected: ")
+		require.Regexp(t, `a\.md -> .*b\.md -> .*a\.md`, err.Error())
+	})
+	t.Run("missing file in strict mode", func(t *testing.T) {
+		dir := testutil.WriteFiles(t, map[string]string{
+			"a.md": "<!-- include:{\"uri\": \"file://b.md\"} -->",
+			"b.md": "text\n<!-- include:{\"uri\": \"file://missing.md\"} -->",
+		})
+
+		err := serializeFile(t, filepath.Join(dir, "a.md"), WithStrict())
+
+		var diagErr *DiagnosticError
+		require.ErrorAs(t, err, &diagErr)
+		require.Len(t, diagErr.Diagnostics, 1)
+		require.True(t, strings.HasSuffix(diagErr.Diagnostics[0].Position.File, "b.md"))
+		require.Equal(t, 2, diagErr.Diagnostics[0].Position.Line)
+		require.Contains(t, diagErr.Diagnostics[0].Message, "could not open included template")
+	})
+}
@@ -2,12 +2,17 @@ package serializer
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 
 	e "github.com/MonkeyBuisness/celli/notebook/errors"
+	"github.com/MonkeyBuisness/celli/notebook/resolver"
 	"github.com/MonkeyBuisness/celli/notebook/types"
 	"github.com/sirupsen/logrus"
 )
@@ -15,6 +20,14 @@ import (
 const (
 	nodeKindText    nodeKind = iota
 	nodeKindComment nodeKind = iota
+	nodeKindCode    nodeKind = iota
+
+	nodeKindFrontMatter nodeKind = iota
+)
+
+const (
+	commentOpenTag  = "<!--"
+	commentCloseTag = "-->"
 )
 
 const (
@@ -23,11 +36,13 @@ const (
 )
 
 var (
-	commentRegexp     = regexp.MustCompile(`(<!--[\s\S]*?-->)`)
-	commentMetaRegexp = regexp.MustCompile(
+	yamlErrorLineRegexp = regexp.MustCompile(`^yaml: line (\d+):`)
+	commentMetaRegexp   = regexp.MustCompile(
 		fmt.Sprintf(`(?P<%s>\w*[\s\S]):(?P<%s>[{|\[]*[\s\S]*[}|\]])?`,
 			subExpCommentKey, subExpCommentPayload),
 	)
+	// commentKeyRegexp matches the keys of the serializable comments (lowercase words with dashes).
+	commentKeyRegexp = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
 )
 
 // Option represents serializer option model.
@@ -36,6 +51,16 @@ type Option func(*Options)
 // Options represents serializer configuration model.
 type Options struct {
 	serializers map[string]types.SerializableComment
+	fencedCode  bool
+	frontMatter bool
+	sourceName  string
+	strict      bool
+	validate    bool
+	includes    bool
+	resolver    types.URIResolver
+	observer    func(path string)
+
+	includeChain []string
 }
 
 // Serializer represents notebook serializer implementation.
@@ -43,6 +68,7 @@ type Serializer struct{}
 
 type documentNode interface {
 	nodeKind() nodeKind
+	startOffset() int
 	render(notebook *types.NotebookData) error
 }
 
@@ -54,11 +80,18 @@ type baseNode struct {
 
 type nodeKind int
 
+// errorDescriber is implemented by the nodes that know the precise position of their render errors.
+type errorDescriber interface {
+	describeError(src *source, err error) (offset int, code string)
+}
+
 type commentNode struct {
 	*baseNode
 
-	payload    []byte
-	serializer types.SerializableComment
+	payload      []byte
+	payloadStart int
+	serializer   types.SerializableComment
+	ctx          *types.CommentContext
 }
 
 type textNode struct {
@@ -75,38 +108,110 @@ func New() Serializer {
 // SerializeNotebook converts markup text to the notebook data implementation.
 func (s *Serializer) SerializeNotebook(
 	source io.Reader, opt ...Option) (*types.NotebookData, error) {
-	// apply incoming options.
-	opts := Options{
-		serializers: make(map[string]types.SerializableComment),
+	opts := newOptions(opt...)
+
+	notebook, diagnostics, err := s.serialize(source, &opts)
+	if err != nil {
+		return nil, err
 	}
-	for _, o := range opt {
-		o(&opts)
+
+	// in strict mode all the problems fail the serialization.
+	if len(diagnostics) != 0 {
+		return nil, &DiagnosticError{
+			Diagnostics: diagnostics,
+		}
 	}
 
+	return notebook, nil
+}
+
+// Validate parses and renders markup text in strict mode
+// and returns all the problems found instead of the notebook data.
+func (s *Serializer) Validate(source io.Reader, opt ...Option) ([]Diagnostic, error) {
+	opts := newOptions(opt...)
+	opts.strict = true
+	opts.validate = true
+
+	_, diagnostics, err := s.serialize(source, &opts)
+
+	return diagnostics, err
+}
+
+func (s *Serializer) serialize(
+	source io.Reader, opts *Options) (*types.NotebookData, []Diagnostic, error) {
 	// read markdown content.
 	var buf bytes.Buffer
 	if _, err := buf.ReadFrom(source); err != nil {
-		return nil, e.ErrReadMarkdownSource.New(err.Error())
+		return nil, nil, e.ErrReadMarkdownSource.New(err.Error())
+	}
+	src := newSource(opts.sourceName, buf.String())
+
+	if opts.includes {
+		if len(opts.includeChain) == 0 && opts.sourceName != "" {
+			if absPath, err := filepath.Abs(opts.sourceName); err == nil {
+				opts.includeChain = []string{absPath}
+			}
+		}
+		opts.serializers[includeKey] = &includeComment{
+			serializer: s,
+			opts:       opts,
+		}
 	}
-	content := buf.String()
 
 	// parse markup content.
-	nodes := s.parseMarkupContent(content, &opts)
+	nodes, warnings := s.parseMarkupContent(src, opts)
+	if !opts.strict {
+		for i := range warnings {
+			logrus.Warn(warnings[i].String())
+		}
+		warnings = nil
+	}
 
 	// render nodes to the notebook document data.
-	return s.renderNotebook(nodes)
+	notebook, diagnostics, err := s.renderNotebook(src, nodes, warnings, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return notebook, diagnostics, nil
 }
 
-func (s *Serializer) parseMarkupContent(content string, opts *Options) []documentNode {
-	// find all HTML comment blocks inside the document.
-	commentIndices := commentRegexp.FindAllStringIndex(content, -1)
+func (s *Serializer) parseMarkupContent(src *source, opts *Options) ([]documentNode, []Diagnostic) {
+	var warnings []Diagnostic
+	// parse problems fail the serialization in strict mode.
+	parseSeverity := SeverityWarning
+	if opts.strict {
+		parseSeverity = SeverityError
+	}
+	content := src.content
+	ctx := &types.CommentContext{
+		SourceName: opts.sourceName,
+		Resolver:   opts.resolver,
+	}
+	// file paths are relative to the template they are referenced from.
+	if opts.sourceName != "" {
+		ctx.Resolver = resolver.NewBaseDirResolver(opts.resolver, filepath.Dir(opts.sourceName))
+	}
+
+	// strip YAML front matter.
+	var nodes []documentNode
+	bodyStart := 0
+	if opts.frontMatter {
+		if frontMatter, ok := parseFrontMatter(content); ok {
+			nodes = append(nodes, frontMatter)
+			bodyStart = frontMatter.end
+		}
+	}
 
-	// split document into nodes.
-	docNodes := splicDocIntoNodes(commentIndices, len(content))
+	// split document into text and HTML comment nodes.
+	docNodes := tokenizeDocument(content[bodyStart:], opts.fencedCode)
+	for i := range docNodes {
+		docNodes[i].start += bodyStart
+		docNodes[i].end += bodyStart
+	}
 	expKeyIndex := commentMetaRegexp.SubexpIndex(subExpCommentKey)
 	expPayloadIndex := commentMetaRegexp.SubexpIndex(subExpCommentPayload)
 
-	nodes := make([]documentNode, len(docNodes))
 	for i := range docNodes {
 		node := &docNodes[i]
 		nodeContent := content[node.start:node.end]
@@ -115,7 +220,7 @@ func (s *Serializer) parseMarkupContent(content string, opts *Options) []documen
 		switch node.kind {
 		case nodeKindComment:
 			// parse comment to extract meta value.
-			metaIndices := commentMetaRegexp.FindStringSubmatch(nodeContent)
+			metaIndices := commentMetaRegexp.FindStringSubmatchIndex(nodeContent)
 
 			// check if comment is a not serializable comment.
 			if len(metaIndices) == 0 {
@@ -123,17 +228,29 @@ func (s *Serializer) parseMarkupContent(content string, opts *Options) []documen
 			}
 
 			// detect comment key.
-			cKey := metaIndices[expKeyIndex]
+			keyStart, keyEnd := metaIndices[2*expKeyIndex], metaIndices[2*expKeyIndex+1]
+			cKey := nodeContent[keyStart:keyEnd]
+
+			// detect comment payload.
+			var cPayload string
+			payloadStart := metaIndices[2*expPayloadIndex]
+			if payloadStart != -1 {
+				cPayload = nodeContent[payloadStart:metaIndices[2*expPayloadIndex+1]]
+			} else {
+				payloadStart = keyEnd
+			}
+
 			serializer, ok := opts.serializers[cKey]
 			if !ok {
-				logrus.Warnf("could not serialize comment at position %d:%d: unknown key %s",
-					node.start, node.end, cKey)
+				// prose comments like <!-- Note: ... --> are not reported.
+				if isCommentKey(cKey, cPayload) {
+					warnings = append(warnings, newDiagnostic(src, parseSeverity,
+						DiagnosticUnknownKey, node.start+keyStart,
+						fmt.Sprintf("could not serialize comment: unknown key %s", cKey)))
+				}
 				break
 			}
 
-			// detect comment payload.
-			cPayload := metaIndices[expPayloadIndex]
-
 			// create comment node.
 			docNode = commentNode{
 				baseNode: &baseNode{
@@ -141,18 +258,42 @@ func (s *Serializer) parseMarkupContent(content string, opts *Options) []documen
 					end:   node.end,
 					kind:  nodeKindComment,
 				},
-				serializer: serializer,
-				payload:    []byte(cPayload),
+				serializer:   serializer,
+				payload:      []byte(cPayload),
+				payloadStart: node.start + payloadStart,
+				ctx:          ctx,
 			}
 		}
 
-		nodes[i] = docNode
+		nodes = append(nodes, docNode)
 	}
 
-	return optimizeNodes(nodes)
+	// comment without the close tag is tokenized as a text.
+	if len(docNodes) != 0 && docNodes[len(docNodes)-1].kind == nodeKindText {
+		lastNode := &docNodes[len(docNodes)-1]
+		if index := unterminatedComment(content, lastNode.start, lastNode.end, opts.fencedCode); index != -1 {
+			warnings = append(warnings, newDiagnostic(src, parseSeverity,
+				DiagnosticUnterminatedComment, index,
+				"unterminated comment: missing "+commentCloseTag))
+		}
+	}
+
+	nodes = optimizeNodes(content, nodes)
+	if opts.fencedCode {
+		nodes = splitFencedCode(nodes)
+	}
+
+	return nodes, warnings
 }
 
-func (s *Serializer) renderNotebook(nodes []documentNode) (*types.NotebookData, error) {
+// renderNotebook renders nodes to the notebook data.
+//
+// In strict mode render errors are collected as diagnostics instead of failing on the first one.
+// Parse warnings are merged with them in the order they are encountered in the document
+// (the problems of the included templates are placed at their include comments).
+func (s *Serializer) renderNotebook(src *source, nodes []documentNode,
+	warnings []Diagnostic, opts *Options) (*types.NotebookData, []Diagnostic, error) {
+	var diagnostics []Diagnostic
 	notebookData := types.NotebookData{
 		Cells:    make([]types.NotebookCellData, 0, len(nodes)),
 		Metadata: make(map[string]interface{}),
@@ -161,18 +302,53 @@ func (s *Serializer) renderNotebook(nodes []documentNode) (*types.NotebookData,
 	for i := range nodes {
 		n := nodes[i]
 
+		for len(warnings) != 0 && warnings[0].Position.Offset < n.startOffset() {
+			diagnostics = append(diagnostics, warnings[0])
+			warnings = warnings[1:]
+		}
+
+		cellsCount := len(notebookData.Cells)
 		if err := n.render(&notebookData); err != nil {
-			return nil, e.ErrRenderNotebook.New(err.Error())
+			// errors of the included templates already contain their positions.
+			var diagErr *DiagnosticError
+			switch {
+			case errors.As(err, &diagErr):
+				diagnostics = append(diagnostics, diagErr.Diagnostics...)
+				continue
+			case errors.Is(err, e.ErrRenderNotebook):
+				return nil, nil, err
+			}
+
+			offset, code := n.startOffset(), DiagnosticRenderError
+			if d, ok := n.(errorDescriber); ok {
+				offset, code = d.describeError(src, err)
+			}
+
+			diagnostic := newDiagnostic(src, SeverityError, code, offset, err.Error())
+			if !opts.strict {
+				return nil, nil, e.ErrRenderNotebook.New(diagnostic.String())
+			}
+			diagnostics = append(diagnostics, diagnostic)
+			continue
+		}
+
+		if opts.validate {
+			diagnostics = append(diagnostics, validateCells(src, n, notebookData.Cells[cellsCount:])...)
 		}
 	}
+	diagnostics = append(diagnostics, warnings...)
 
-	return &notebookData, nil
+	return &notebookData, diagnostics, nil
 }
 
 func (n *baseNode) nodeKind() nodeKind {
 	return n.kind
 }
 
+func (n *baseNode) startOffset() int {
+	return n.start
+}
+
 func (n textNode) render(notebook *types.NotebookData) error {
 	content := strings.TrimSpace(n.content)
 	if content == "" {
@@ -189,9 +365,76 @@ func (n textNode) render(notebook *types.NotebookData) error {
 }
 
 func (n commentNode) render(notebook *types.NotebookData) error {
+	if s, ok := n.serializer.(types.ContextualComment); ok {
+		return s.RenderContext(n.ctx, notebook, n.payload)
+	}
+
 	return n.serializer.Render(notebook, n.payload)
 }
 
+// describeError returns the document offset and the diagnostic code of the render error.
+// Offset of the payload error is returned if it is known, or the offset of the comment otherwise.
+func (n commentNode) describeError(src *source, err error) (offset int, code string) {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		return n.payloadStart + int(syntaxErr.Offset) - 1, DiagnosticInvalidPayload
+	case errors.As(err, &typeErr):
+		return n.payloadStart + int(typeErr.Offset) - 1, DiagnosticInvalidPayload
+	}
+
+	// YAML payloads are parsed without the surrounding braces.
+	if offset, ok := yamlErrorOffset(src, n.payloadStart+1, err); ok {
+		return offset, DiagnosticInvalidPayload
+	}
+
+	return n.start, DiagnosticRenderError
+}
+
+// yamlErrorOffset returns the offset of the line the YAML error refers to.
+func yamlErrorOffset(src *source, payloadStart int, err error) (int, bool) {
+	m := yamlErrorLineRegexp.FindStringSubmatch(err.Error())
+	if len(m) == 0 {
+		return 0, false
+	}
+
+	line, _ := strconv.Atoi(m[1])
+	payloadLine := src.position(payloadStart).Line + line - 1
+	if payloadLine > len(src.lineStarts) {
+		return 0, false
+	}
+
+	return src.lineStarts[payloadLine-1], true
+}
+
+// isCommentKey reports whether the comment looks like a serializable one:
+// lowercase key followed by a JSON/YAML object or array payload.
+func isCommentKey(key, payload string) bool {
+	if !commentKeyRegexp.MatchString(key) {
+		return false
+	}
+
+	return strings.HasPrefix(payload, "{") || strings.HasPrefix(payload, "[")
+}
+
+// validateCells checks the cells rendered by the node.
+func validateCells(src *source, n documentNode, cells []types.NotebookCellData) []Diagnostic {
+	var diagnostics []Diagnostic
+	for i := range cells {
+		c := &cells[i]
+		if c.Kind == types.NotebookCellKindCode && strings.TrimSpace(c.Content) == "" {
+			diagnostics = append(diagnostics, newDiagnostic(src, SeverityWarning,
+				DiagnosticEmptyCodeCell, n.startOffset(), "empty code cell"))
+		}
+	}
+
+	return diagnostics
+}
+
 // WithCommentSerializer adds a new comment serializer.
 func WithCommentSerializer(s ...types.SerializableComment) Option {
 	return func(o *Options) {
@@ -201,55 +444,156 @@ func WithCommentSerializer(s ...types.SerializableComment) Option {
 	}
 }
 
-func splicDocIntoNodes(commentIndices [][]int, docLen int) []baseNode {
-	nodes := make([]baseNode, 0, len(commentIndices))
+func newOptions(opt ...Option) Options {
+	opts := Options{
+		serializers: make(map[string]types.SerializableComment),
+	}
+	for _, o := range opt {
+		o(&opts)
+	}
 
-	commentByIndex := func(index int) (start, end int) {
-		start, end = -1, -1
+	if opts.resolver == nil {
+		opts.resolver = resolver.Default()
+	}
 
-		for i := range commentIndices {
-			cm := commentIndices[i]
+	return opts
+}
 
-			if index >= cm[0] && index <= cm[1] {
-				start, end = cm[0], cm[1]
-				return
-			}
-		}
+// WithSourceName sets the name of the template source (e.g. file name) used in the error messages.
+func WithSourceName(name string) Option {
+	return func(o *Options) {
+		o.sourceName = name
+	}
+}
+
+// WithStrict enables strict mode: unknown comment keys, malformed comments and render errors
+// are collected and returned as DiagnosticError instead of being logged or failing on the first one.
+func WithStrict() Option {
+	return func(o *Options) {
+		o.strict = true
+	}
+}
 
-		return
+// WithFrontMatter enables YAML front matter at the top of the template
+// that is merged into the notebook metadata instead of being the part of the first markup cell.
+func WithFrontMatter() Option {
+	return func(o *Options) {
+		o.frontMatter = true
 	}
+}
 
-	txtNode := baseNode{
-		start: 0,
-		end:   0,
-		kind:  nodeKindText,
+// WithURIResolver sets resolver used to read the content of the URIs referenced from the comments.
+//
+// resolver.Default() is used if it is not provided.
+func WithURIResolver(r types.URIResolver) Option {
+	return func(o *Options) {
+		o.resolver = r
 	}
-	for i := 0; i < docLen; i++ {
-		if commStart, commEnd := commentByIndex(i); commStart != -1 {
-			if txtNode.end != 0 {
-				nodes = append(nodes, txtNode)
+}
+
+// WithIncludes enables <!-- include:{"uri": "file://..."} --> comments
+// that parse the referenced template in place with the same options.
+func WithIncludes() Option {
+	return func(o *Options) {
+		o.includes = true
+	}
+}
+
+// WithIncludeObserver sets the function that is called with the path of every included template,
+// even if it could not be read (e.g. to watch it for changes).
+func WithIncludeObserver(observer func(path string)) Option {
+	return func(o *Options) {
+		o.observer = observer
+	}
+}
+
+// WithFencedCode enables conversion of the fenced code blocks with a language
+// (```java) to the code cells.
+func WithFencedCode() Option {
+	return func(o *Options) {
+		o.fencedCode = true
+	}
+}
+
+// tokenizeDocument splits document into text and comment nodes in a single pass,
+// so the cost is linear to the document length.
+//
+// If fencedCode is set, comments inside the fenced code blocks are kept as a text.
+func tokenizeDocument(content string, fencedCode bool) []baseNode {
+	nodes := make([]baseNode, 0)
+
+	textStart, offset := 0, 0
+	for {
+		commStart := strings.Index(content[offset:], commentOpenTag)
+		if commStart == -1 {
+			break
+		}
+		commStart += offset
+
+		if fencedCode {
+			if blockEnd, ok := fencedBlockEnd(content, textStart, commStart); ok {
+				offset = blockEnd
+				continue
 			}
+		}
+
+		commEnd := strings.Index(content[commStart+len(commentOpenTag):], commentCloseTag)
+		if commEnd == -1 {
+			break
+		}
+		commEnd += commStart + len(commentOpenTag) + len(commentCloseTag)
+
+		if commStart > textStart {
 			nodes = append(nodes, baseNode{
-				start: commStart,
-				end:   commEnd,
-				kind:  nodeKindComment,
+				start: textStart,
+				end:   commStart,
+				kind:  nodeKindText,
 			})
-			i = commEnd
-			txtNode.start = i + 1
-			txtNode.end = 0
-			continue
 		}
-
-		txtNode.end = i
+		nodes = append(nodes, baseNode{
+			start: commStart,
+			end:   commEnd,
+			kind:  nodeKindComment,
+		})
+		textStart, offset = commEnd, commEnd
 	}
 
-	if txtNode.end != 0 {
-		nodes = append(nodes, txtNode)
+	if textStart < len(content) {
+		nodes = append(nodes, baseNode{
+			start: textStart,
+			end:   len(content),
+			kind:  nodeKindText,
+		})
 	}
 
 	return nodes
 }
 
+// unterminatedComment returns the position of the comment open tag left in the text node
+// or -1 if there is no one (open tags inside the fenced code blocks are skipped if fencedCode is set).
+func unterminatedComment(content string, start, end int, fencedCode bool) int {
+	offset := start
+	for {
+		index := strings.Index(content[offset:end], commentOpenTag)
+		if index == -1 {
+			return -1
+		}
+		index += offset
+
+		if !fencedCode {
+			return index
+		}
+		blockEnd, ok := fencedBlockEnd(content, start, index)
+		if !ok {
+			return index
+		}
+		offset = blockEnd
+		if offset > end {
+			return -1
+		}
+	}
+}
+
 func newTextNode(start, end int, content string) textNode {
 	return textNode{
 		baseNode: &baseNode{
@@ -261,7 +605,7 @@ func newTextNode(start, end int, content string) textNode {
 	}
 }
 
-func optimizeNodes(nodes []documentNode) []documentNode {
+func optimizeNodes(content string, nodes []documentNode) []documentNode {
 	optimizedNodes := make([]documentNode, 0, len(nodes))
 
 	var (
@@ -273,7 +617,7 @@ func optimizeNodes(nodes []documentNode) []documentNode {
 		if prevNode != nil && n.nodeKind() == nodeKindText && prevNode.nodeKind() == nodeKindText {
 			tPrevNode := prevNode.(textNode)
 			tNode := n.(textNode)
-			tPrevNode.content = fmt.Sprintf("%s\n%s", tPrevNode.content, tNode.content)
+			tPrevNode.content = content[tPrevNode.start:tNode.end]
 			tPrevNode.end = tNode.end
 			optimizedNodes[prevTextNodeIndex] = tPrevNode
 			prevNode = optimizedNodes[prevTextNodeIndex]
@@ -0,0 +1,373 @@
+package serializer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	e "github.com/MonkeyBuisness/celli/notebook/errors"
+	"github.com/MonkeyBuisness/celli/notebook/lock"
+	"github.com/MonkeyBuisness/celli/notebook/resolver"
+	"github.com/MonkeyBuisness/celli/notebook/serializer/comments"
+	"github.com/MonkeyBuisness/celli/notebook/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSerializer_SerializeNotebook_FencedCode(t *testing.T) {
+	const doc = "# Title\n\nSome text.\n\n" +
+		"```java {meta=\"is-executable=false, file-name=Main, timeout=5\"}\n" +
+		"class Main {}\n" +
+		"```\n\n" +
+		"Text between.\n\n" +
+		"```\nplain block\n```\n\n" +
+		"<!-- br: -->\n\n" +
+		"~~~~go\nfunc main() {}\n~~~~\n"
+
+	t.Run("disabled", func(t *testing.T) {
+		s := New()
+		notebook, err := s.SerializeNotebook(strings.NewReader(doc),
+			WithCommentSerializer(comments.NewBrCommentSerializer()),
+		)
+		require.NoError(t, err)
+		for _, c := range notebook.Cells {
+			require.Equal(t, types.NotebookCellKindMarkup, c.Kind)
+		}
+	})
+	t.Run("all ok", func(t *testing.T) {
+		s := New()
+		notebook, err := s.SerializeNotebook(strings.NewReader(doc),
+			WithCommentSerializer(comments.NewBrCommentSerializer()),
+			WithFencedCode(),
+		)
+		require.NoError(t, err)
+		require.Equal(t, []types.NotebookCellData{
+			{
+				LanguageID: types.MarkdownLanguageID,
+				Content:    "# Title\n\nSome text.",
+				Kind:       types.NotebookCellKindMarkup,
+			},
+			{
+				LanguageID: "java",
+				Content:    "class Main {}",
+				Kind:       types.NotebookCellKindCode,
+				Metadata: map[string]interface{}{
+					"is-executable": false,
+					"file-name":     "Main",
+					"timeout":       5.0,
+					types.SourceMetadataKey: map[string]interface{}{
+						"source": map[string]interface{}{"style": "fence"},
+					},
+				},
+			},
+			{
+				LanguageID: types.MarkdownLanguageID,
+				Content:    "Text between.\n\n```\nplain block\n```",
+				Kind:       types.NotebookCellKindMarkup,
+			},
+			{
+				LanguageID: "go",
+				Content:    "func main() {}",
+				Kind:       types.NotebookCellKindCode,
+				Metadata: map[string]interface{}{
+					types.SourceMetadataKey: map[string]interface{}{
+						"source": map[string]interface{}{"style": "fence"},
+					},
+				},
+			},
+		}, notebook.Cells)
+	})
+	t.Run("comments inside fence", func(t *testing.T) {
+		const content = "<!-- br: -->\n<p>text</p>\n<!-- note"
+		s := New()
+		// strict mode fails on the unterminated comment outside the fence.
+		notebook, err := s.SerializeNotebook(strings.NewReader(
+			"text\n\n```html\n"+content+"\n```\n\n<!-- br: -->\n\nafter"),
+			WithCommentSerializer(comments.NewBrCommentSerializer()),
+			WithFencedCode(),
+			WithStrict(),
+		)
+		require.NoError(t, err)
+		require.Len(t, notebook.Cells, 3)
+		require.Equal(t, types.NotebookCellKindCode, notebook.Cells[1].Kind)
+		require.Equal(t, content, notebook.Cells[1].Content)
+		require.Equal(t, "after", notebook.Cells[2].Content)
+	})
+	t.Run("unclosed fence", func(t *testing.T) {
+		s := New()
+		notebook, err := s.SerializeNotebook(strings.NewReader("text\n\n```java\nclass Main {}\n"),
+			WithFencedCode(),
+		)
+		require.NoError(t, err)
+		require.Len(t, notebook.Cells, 1)
+		require.Equal(t, types.NotebookCellKindMarkup, notebook.Cells[0].Kind)
+	})
+}
+
+func Test_tokenizeDocument(t *testing.T) {
+	t.Run("no comments", func(t *testing.T) {
+		require.Equal(t, []baseNode{
+			{start: 0, end: 4, kind: nodeKindText},
+		}, tokenizeDocument("text", false))
+	})
+	t.Run("empty document", func(t *testing.T) {
+		require.Empty(t, tokenizeDocument("", false))
+	})
+	t.Run("all ok", func(t *testing.T) {
+		const doc = "<!-- br: -->a\n<!-- x --><!-- y -->b"
+		nodes := tokenizeDocument(doc, false)
+		require.Equal(t, []baseNode{
+			{start: 0, end: 12, kind: nodeKindComment},
+			{start: 12, end: 14, kind: nodeKindText},
+			{start: 14, end: 24, kind: nodeKindComment},
+			{start: 24, end: 34, kind: nodeKindComment},
+			{start: 34, end: 35, kind: nodeKindText},
+		}, nodes)
+
+		// nodes must cover the whole document.
+		var sb strings.Builder
+		for _, n := range nodes {
+			sb.WriteString(doc[n.start:n.end])
+		}
+		require.Equal(t, doc, sb.String())
+	})
+}
+
+func TestSerializer_SerializeNotebook_KeepsText(t *testing.T) {
+	s := New()
+	notebook, err := s.SerializeNotebook(strings.NewReader("first.<!-- br: -->second <!-- note --> end"),
+		WithCommentSerializer(comments.NewBrCommentSerializer()),
+	)
+	require.NoError(t, err)
+	require.Len(t, notebook.Cells, 2)
+	require.Equal(t, "first.", notebook.Cells[0].Content)
+	require.Equal(t, "second <!-- note --> end", notebook.Cells[1].Content)
+}
+
+func TestSerializer_SerializeNotebook_ErrorPosition(t *testing.T) {
+	const doc = "# Title\n\n<!-- code:{\n\t\"lang\": \"java\",\n} -->\n"
+
+	s := New()
+	_, err := s.SerializeNotebook(strings.NewReader(doc),
+		WithSourceName("book.md"),
+		WithCommentSerializer(comments.NewCodeCommentSerializer()),
+	)
+	require.EqualError(t, err, "could not render notebook data: "+
+		"book.md:5:1: invalid character '}' looking for beginning of object key string\n"+
+		" 5 | } -->\n"+
+		"   | ^")
+}
+
+func Test_source_position(t *testing.T) {
+	src := newSource("", "ab\n\tcd\nяz")
+	require.Equal(t, Position{Offset: 0, Line: 1, Column: 1}, src.position(0))
+	require.Equal(t, Position{Offset: 5, Line: 2, Column: 3}, src.position(5))
+	require.Equal(t, Position{Offset: 9, Line: 3, Column: 2}, src.position(9))
+	require.Equal(t, "2:3", src.position(5).String())
+	require.Equal(t, " 2 | \tcd\n   | \t ^", src.excerpt(5))
+}
+
+func TestSerializer_SerializeNotebook_Strict(t *testing.T) {
+	const doc = "<!-- cdoe:{} -->\n<!-- code:{\"lang\": 1} -->\n<!-- br: -->\n<!-- open"
+
+	t.Run("not strict", func(t *testing.T) {
+		s := New()
+		_, err := s.SerializeNotebook(strings.NewReader("<!-- cdoe:{} -->\ntext"),
+			WithCommentSerializer(comments.NewCodeCommentSerializer()),
+		)
+		require.NoError(t, err)
+	})
+	t.Run("all problems collected", func(t *testing.T) {
+		s := New()
+		_, err := s.SerializeNotebook(strings.NewReader(doc),
+			WithStrict(),
+			WithCommentSerializer(
+				comments.NewCodeCommentSerializer(),
+				comments.NewBrCommentSerializer(),
+			),
+		)
+		require.True(t, errors.Is(err, e.ErrInvalidTemplate))
+
+		var diagErr *DiagnosticError
+		require.True(t, errors.As(err, &diagErr))
+		require.Len(t, diagErr.Diagnostics, 3)
+
+		require.Equal(t, SeverityError, diagErr.Diagnostics[0].Severity)
+		require.Equal(t, Position{Offset: 5, Line: 1, Column: 6}, diagErr.Diagnostics[0].Position)
+		require.Equal(t, "could not serialize comment: unknown key cdoe", diagErr.Diagnostics[0].Message)
+
+		require.Equal(t, SeverityError, diagErr.Diagnostics[1].Severity)
+		require.Equal(t, 2, diagErr.Diagnostics[1].Position.Line)
+		require.Equal(t, 20, diagErr.Diagnostics[1].Position.Column)
+
+		require.Equal(t, SeverityError, diagErr.Diagnostics[2].Severity)
+		require.Equal(t, 4, diagErr.Diagnostics[2].Position.Line)
+	})
+	t.Run("prose comments", func(t *testing.T) {
+		s := New()
+		notebook, err := s.SerializeNotebook(
+			strings.NewReader("text<!-- Note: remember to update -->\n<!-- todo: see [1] -->"),
+			WithStrict(),
+			WithCommentSerializer(comments.NewBrCommentSerializer()),
+		)
+		require.NoError(t, err)
+		require.Len(t, notebook.Cells, 1)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		s := New()
+		notebook, err := s.SerializeNotebook(strings.NewReader("text<!-- br: -->"),
+			WithStrict(),
+			WithCommentSerializer(comments.NewBrCommentSerializer()),
+		)
+		require.NoError(t, err)
+		require.Len(t, notebook.Cells, 1)
+	})
+}
+
+func TestSerializer_Validate(t *testing.T) {
+	s := New()
+	diagnostics, err := s.Validate(strings.NewReader("<!-- code:{\"lang\": \"java\"} -->\n<!-- x:{} -->"),
+		WithCommentSerializer(comments.NewCodeCommentSerializer()),
+	)
+	require.NoError(t, err)
+	require.Len(t, diagnostics, 2)
+	require.Equal(t, DiagnosticEmptyCodeCell, diagnostics[0].Code)
+	require.Equal(t, DiagnosticUnknownKey, diagnostics[1].Code)
+}
+
+func TestSerializer_SerializeNotebook_URIResolver(t *testing.T) {
+	s := New()
+	notebook, err := s.SerializeNotebook(
+		strings.NewReader("<!-- code:{\"lang\": \"java\", \"uri\": \"mem://Main.java\"} -->\n"+
+			"<!-- ycode:{\n    lang: java\n    uri: mem://Other.java\n} -->"),
+		WithCommentSerializer(
+			comments.NewCodeCommentSerializer(),
+			comments.NewYCodeCommentSerializer(),
+		),
+		WithURIResolver(resolver.NewRegistry().Register(resolver.SchemeMem, resolver.NewMemoryResolver(
+			map[string][]byte{
+				"mem://Main.java":  []byte("class Main {}"),
+				"mem://Other.java": []byte("class Other {}"),
+			},
+		))),
+	)
+	require.NoError(t, err)
+	require.Len(t, notebook.Cells, 2)
+	require.Equal(t, "class Main {}", notebook.Cells[0].Content)
+	require.Equal(t, "class Other {}", notebook.Cells[1].Content)
+}
+
+func TestSerializer_SerializeNotebook_SHA256(t *testing.T) {
+	uriResolver := resolver.NewRegistry().Register(resolver.SchemeMem, resolver.NewMemoryResolver(
+		map[string][]byte{
+			"mem://Main.java": []byte("class Main {}"),
+		},
+	))
+	template := func(hash string) *strings.Reader {
+		return strings.NewReader(
+			"<!-- code:{\"lang\": \"java\", \"uri\": \"mem://Main.java\", \"sha256\": \"" + hash + "\"} -->")
+	}
+
+	t.Run("all ok", func(t *testing.T) {
+		s := New()
+		notebook, err := s.SerializeNotebook(template(lock.Hash([]byte("class Main {}"))),
+			WithCommentSerializer(comments.NewCodeCommentSerializer()),
+			WithURIResolver(uriResolver),
+		)
+		require.NoError(t, err)
+		require.Len(t, notebook.Cells, 1)
+
+		src, ok := notebook.Cells[0].Source()
+		require.True(t, ok)
+		require.Equal(t, lock.Hash([]byte("class Main {}")), src.SHA256)
+	})
+	t.Run("mismatch", func(t *testing.T) {
+		s := New()
+		_, err := s.SerializeNotebook(template("abc"),
+			WithCommentSerializer(comments.NewCodeCommentSerializer()),
+			WithURIResolver(uriResolver),
+		)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "mem://Main.java: sha256 mismatch")
+	})
+}
+
+func TestSerializer_SerializeNotebook_FrontMatter(t *testing.T) {
+	t.Run("all ok", func(t *testing.T) {
+		const doc = "---\ntitle: Loops\nversion: 1\ntags: [java]\n---\n" +
+			"<!-- notebook:{\"version\": 2} -->\n# Title\n"
+
+		s := New()
+		notebook, err := s.SerializeNotebook(strings.NewReader(doc),
+			WithFrontMatter(),
+			WithCommentSerializer(comments.NewNotebookCommentSerializer()),
+		)
+		require.NoError(t, err)
+		require.Len(t, notebook.Cells, 1)
+		require.Equal(t, "# Title", notebook.Cells[0].Content)
+		require.Equal(t, map[string]interface{}{
+			"title":   "Loops",
+			"version": float64(2),
+			"tags":    []interface{}{"java"},
+		}, notebook.Metadata)
+	})
+	t.Run("disabled", func(t *testing.T) {
+		s := New()
+		notebook, err := s.SerializeNotebook(strings.NewReader("---\ntitle: Loops\n---\n# Title\n"))
+		require.NoError(t, err)
+		require.Len(t, notebook.Cells, 1)
+		require.Equal(t, "---\ntitle: Loops\n---\n# Title", notebook.Cells[0].Content)
+		require.Empty(t, notebook.Metadata)
+	})
+	t.Run("not closed", func(t *testing.T) {
+		s := New()
+		notebook, err := s.SerializeNotebook(strings.NewReader("---\ntitle: Loops\n"), WithFrontMatter())
+		require.NoError(t, err)
+		require.Len(t, notebook.Cells, 1)
+		require.Empty(t, notebook.Metadata)
+	})
+	t.Run("invalid yaml", func(t *testing.T) {
+		s := New()
+		diagnostics, err := s.Validate(strings.NewReader("---\ntitle: Loops\ntags: [java\n---\n"), WithFrontMatter())
+		require.NoError(t, err)
+		require.Len(t, diagnostics, 1)
+		require.Equal(t, DiagnosticInvalidPayload, diagnostics[0].Code)
+		require.Equal(t, 3, diagnostics[0].Position.Line)
+	})
+	t.Run("not a mapping", func(t *testing.T) {
+		s := New()
+		_, err := s.SerializeNotebook(strings.NewReader("---\n- java\n---\n"), WithFrontMatter())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "front matter must be a YAML mapping")
+	})
+}
+
+func TestSerializer_SerializeNotebook_Output(t *testing.T) {
+	t.Run("all ok", func(t *testing.T) {
+		const doc = "<!-- code:{\"lang\": \"java\", \"content\": \"System.out.println(1);\"} -->\n" +
+			"<!-- output:{\"items\": [{\"mime\": \"text/plain\", \"data\": \"1\"}]} -->\n" +
+			"<!-- output:{\"items\": [{\"mime\": \"text/html\", \"data\": \"<b>1</b>\"}]} -->\n"
+
+		s := New()
+		notebook, err := s.SerializeNotebook(strings.NewReader(doc),
+			WithCommentSerializer(
+				comments.NewCodeCommentSerializer(),
+				comments.NewOutputCommentSerializer(),
+			),
+		)
+		require.NoError(t, err)
+		require.Len(t, notebook.Cells, 1)
+		require.Equal(t, []types.NotebookCellOutput{
+			{Items: []types.NotebookCellOutputItem{{Mime: "text/plain", Data: "1"}}},
+			{Items: []types.NotebookCellOutputItem{{Mime: "text/html", Data: "<b>1</b>"}}},
+		}, notebook.Cells[0].Outputs)
+	})
+	t.Run("no code cell", func(t *testing.T) {
+		s := New()
+		_, err := s.SerializeNotebook(
+			strings.NewReader("# Title\n<!-- output:{\"items\": [{\"mime\": \"text/plain\", \"data\": \"1\"}]} -->"),
+			WithCommentSerializer(comments.NewOutputCommentSerializer()),
+		)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "output comment must follow the code cell")
+	})
+}
@@ -0,0 +1,102 @@
+package serializer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Position represents a position inside the template source.
+type Position struct {
+	File   string `json:"file,omitempty"`
+	Offset int    `json:"offset"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// source represents template source with the index of line offsets.
+type source struct {
+	name       string
+	content    string
+	lineStarts []int
+}
+
+func newSource(name, content string) *source {
+	lineStarts := []int{0}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+
+	return &source{
+		name:       name,
+		content:    content,
+		lineStarts: lineStarts,
+	}
+}
+
+// String returns position in the `file:line:column` format.
+func (p Position) String() string {
+	if p.File == "" {
+		return fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// position converts byte offset to the line and column (both are 1-based) position.
+func (s *source) position(offset int) Position {
+	offset = s.clampOffset(offset)
+	line := sort.Search(len(s.lineStarts), func(i int) bool {
+		return s.lineStarts[i] > offset
+	}) - 1
+
+	return Position{
+		File:   s.name,
+		Offset: offset,
+		Line:   line + 1,
+		Column: utf8.RuneCountInString(s.content[s.lineStarts[line]:offset]) + 1,
+	}
+}
+
+// excerpt returns the source line of the offset with a caret pointing to the column.
+func (s *source) excerpt(offset int) string {
+	pos := s.position(offset)
+
+	lineStart := s.lineStarts[pos.Line-1]
+	lineEnd := len(s.content)
+	if pos.Line < len(s.lineStarts) {
+		lineEnd = s.lineStarts[pos.Line] - 1
+	}
+	line := strings.TrimRight(s.content[lineStart:lineEnd], "\r")
+
+	// keep tabs to align the caret with the source line.
+	var caret strings.Builder
+	for _, r := range s.content[lineStart:pos.Offset] {
+		if r == '\t' {
+			caret.WriteRune('\t')
+			continue
+		}
+		caret.WriteRune(' ')
+	}
+	caret.WriteRune('^')
+
+	lineNumber := fmt.Sprint(pos.Line)
+
+	return fmt.Sprintf(" %s | %s\n %s | %s",
+		lineNumber, line, strings.Repeat(" ", len(lineNumber)), caret.String())
+}
+
+func (s *source) clampOffset(offset int) int {
+	if offset < 0 {
+		return 0
+	}
+
+	if offset > len(s.content) {
+		return len(s.content)
+	}
+
+	return offset
+}
@@ -0,0 +1,154 @@
+package template
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/MonkeyBuisness/celli/notebook/config"
+	"github.com/MonkeyBuisness/celli/notebook/types"
+)
+
+const (
+	bookSettingsDirName = "books"
+	bookSettingsFileExt = ".json"
+	userConfigDirName   = "celli"
+)
+
+// bookSettingsDirs returns directories with the book type definitions in the order of precedence:
+// definitions of the project override definitions of the user.
+//
+// The project directory is found by walking up from the working directory.
+var bookSettingsDirs = func() []string {
+	var dirs []string
+	if configDir, err := os.UserConfigDir(); err == nil {
+		dirs = append(dirs, filepath.Join(configDir, userConfigDirName, bookSettingsDirName))
+	}
+
+	return append(dirs, filepath.Join(config.FindRoot("."), config.ProjectDirName, bookSettingsDirName))
+}
+
+// SupportedBookTypes returns sorted names of the built-in and user defined book types.
+func SupportedBookTypes() ([]string, error) {
+	settings, err := getBookSettings()
+	if err != nil {
+		return nil, fmt.Errorf("could not read book settings: %v", err)
+	}
+
+	bookTypes := make([]string, 0, len(settings))
+	for bookType := range settings {
+		bookTypes = append(bookTypes, string(bookType))
+	}
+	sort.Strings(bookTypes)
+
+	return bookTypes, nil
+}
+
+// BookExtension returns notebook file extension of the book type.
+func BookExtension(bookType types.BookType) (string, error) {
+	settings, err := getBookSettings()
+	if err != nil {
+		return "", fmt.Errorf("could not read book settings: %v", err)
+	}
+
+	s, ok := settings[bookType]
+	if !ok {
+		return "", fmt.Errorf("could not find book settings for type %s", bookType)
+	}
+
+	return s.extension(bookType), nil
+}
+
+// BookTypeByExtension returns the book type which notebooks have the file extension.
+func BookTypeByExtension(ext string) (types.BookType, bool, error) {
+	extensions, err := BookExtensions()
+	if err != nil {
+		return "", false, err
+	}
+
+	bookType, ok := extensions[strings.ToLower(ext)]
+
+	return bookType, ok, nil
+}
+
+// BookExtensions returns book types keyed by the lower-cased notebook file extension.
+func BookExtensions() (map[string]types.BookType, error) {
+	settings, err := getBookSettings()
+	if err != nil {
+		return nil, fmt.Errorf("could not read book settings: %v", err)
+	}
+
+	extensions := make(map[string]types.BookType, len(settings))
+	for bookType, s := range settings {
+		extensions[strings.ToLower(s.extension(bookType))] = bookType
+	}
+
+	return extensions, nil
+}
+
+// extension returns notebook file extension of the book type (`.<book type>` by default).
+func (s bookSettings) extension(bookType types.BookType) string {
+	if s.Extension == "" {
+		return "." + string(bookType)
+	}
+
+	if !strings.HasPrefix(s.Extension, ".") {
+		return "." + s.Extension
+	}
+
+	return s.Extension
+}
+
+func parseBookSettings(data []byte) (map[types.BookType]bookSettings, error) {
+	var settings map[types.BookType]bookSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, err
+	}
+
+	// book types are case insensitive.
+	normalized := make(map[types.BookType]bookSettings, len(settings))
+	for bookType, s := range settings {
+		normalized[types.BookType(strings.ToLower(string(bookType)))] = s
+	}
+
+	return normalized, nil
+}
+
+// readBookSettingsDir reads book settings from all the *.json files of the directory.
+func readBookSettingsDir(dir string) (map[types.BookType]bookSettings, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	settings := make(map[types.BookType]bookSettings)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), bookSettingsFileExt) {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return nil, err
+		}
+
+		fileSettings, err := parseBookSettings(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+
+		for bookType, s := range fileSettings {
+			settings[bookType] = s
+		}
+	}
+
+	return settings, nil
+}
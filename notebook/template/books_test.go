@@ -0,0 +1,85 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MonkeyBuisness/celli/notebook/types"
+	"github.com/stretchr/testify/require"
+)
+
+func withBookSettingsDirs(t *testing.T, dirs ...string) {
+	t.Helper()
+
+	tmpDirs := bookSettingsDirs
+	bookSettingsDirs = func() []string {
+		return dirs
+	}
+	t.Cleanup(func() {
+		bookSettingsDirs = tmpDirs
+	})
+}
+
+func Test_SupportedBookTypes(t *testing.T) {
+	userDir, projectDir := t.TempDir(), t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(userDir, "books.json"), []byte(`{
+		"SQLBook": {"code": {"lang": "sql", "content": "SELECT 1;"}},
+		"kotlinbook": {"code": {"lang": "kotlin"}, "extension": "kt"}
+	}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "kotlin.json"), []byte(`{
+		"kotlinbook": {"code": {"lang": "kotlin"}, "extension": ".ktbook"}
+	}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "README.md"), []byte(`# Books`), 0o600))
+
+	withBookSettingsDirs(t, userDir, projectDir, filepath.Join(projectDir, "missing"))
+
+	bookTypes, err := SupportedBookTypes()
+	require.NoError(t, err)
+	require.Equal(t, []string{"javabook", "kotlinbook", "sqlbook"}, bookTypes)
+
+	for ext, expected := range map[string]types.BookType{
+		".javabook": types.BookTypeJavaBook,
+		".ktbook":   "kotlinbook",
+		".sqlbook":  "sqlbook",
+		".kt":       "",
+	} {
+		bookType, ok, err := BookTypeByExtension(ext)
+		require.NoError(t, err)
+		require.Equal(t, expected != "", ok, ext)
+		require.Equal(t, expected, bookType, ext)
+	}
+
+	data, err := NewBookTemplate("sqlbook")
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"content": "SELECT 1;"`)
+}
+
+func Test_SupportedBookTypes_InvalidFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "books.json"), []byte(`{`), 0o600))
+
+	withBookSettingsDirs(t, dir)
+
+	_, err := SupportedBookTypes()
+	require.EqualError(t, err, "could not read book settings: "+
+		filepath.Join(dir, "books.json")+": unexpected end of JSON input")
+}
+
+func Test_bookSettingsDirs(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	workDir := filepath.Join(root, "course", "loops")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".celli", "books"), 0o700))
+	require.NoError(t, os.MkdirAll(workDir, 0o700))
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(workDir))
+	t.Cleanup(func() {
+		require.NoError(t, os.Chdir(wd))
+	})
+
+	dirs := bookSettingsDirs()
+	require.Equal(t, filepath.Join(root, ".celli", "books"), dirs[len(dirs)-1])
+}
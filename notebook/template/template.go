@@ -5,6 +5,9 @@ import (
 	"embed"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
 	"time"
@@ -15,6 +18,7 @@ import (
 
 const (
 	bookTemplateFile = "book.tpl.md"
+	bookTemplateExt  = ".tpl.md"
 )
 
 //go:embed book.tpl.md
@@ -24,18 +28,31 @@ var bookTemplate embed.FS
 var bookSettingsData []byte
 
 type bookSettings struct {
-	Notebook json.RawMessage `json:"notebook,omitempty"`
-	Code     json.RawMessage `json:"code,omitempty"`
-	Author   json.RawMessage `json:"author,omitempty"`
+	Notebook  json.RawMessage `json:"notebook,omitempty"`
+	Code      json.RawMessage `json:"code,omitempty"`
+	Author    json.RawMessage `json:"author,omitempty"`
+	Extension string          `json:"extension,omitempty"`
+}
+
+// Option represents book template option model.
+type Option func(*Options)
+
+// Options represents book template configuration model.
+type Options struct {
+	templatePaths []string
 }
 
 // NewBookTemplate creates a book template according to the provided book type.
-func NewBookTemplate(bookType types.BookType) ([]byte, error) {
-	t := template.Must(template.
-		New(bookTemplateFile).
-		Funcs(defaultTemplateFuncs()).
-		ParseFS(bookTemplate, "*.tpl.md"),
-	)
+func NewBookTemplate(bookType types.BookType, opt ...Option) ([]byte, error) {
+	var opts Options
+	for _, o := range opt {
+		o(&opts)
+	}
+
+	t, err := parseBookTemplate(opts.templatePaths)
+	if err != nil {
+		return nil, err
+	}
 
 	booksSettings, err := getBookSettings()
 	if err != nil {
@@ -55,12 +72,98 @@ func NewBookTemplate(bookType types.BookType) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// WithTemplatePaths sets user template files (or directories) to execute instead of the embedded template.
+//
+// The first file (or book.tpl.md of the first directory) is executed, the rest ones
+// are parsed after it, so they can override its {{ block }} sections with {{ define }}.
+func WithTemplatePaths(paths ...string) Option {
+	return func(o *Options) {
+		o.templatePaths = append(o.templatePaths, paths...)
+	}
+}
+
+func parseBookTemplate(paths []string) (*template.Template, error) {
+	if len(paths) == 0 {
+		return template.Must(template.
+			New(bookTemplateFile).
+			Funcs(defaultTemplateFuncs()).
+			ParseFS(bookTemplate, "*.tpl.md"),
+		), nil
+	}
+
+	files, err := templateFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	// files are named by their paths, so the files with the same name from the different directories do not clash.
+	t := template.New(files[0]).Funcs(defaultTemplateFuncs())
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Clean(file))
+		if err != nil {
+			return nil, fmt.Errorf("could not read book template: %v", err)
+		}
+
+		if _, err := t.New(file).Parse(string(data)); err != nil {
+			return nil, fmt.Errorf("could not parse book template: %v", err)
+		}
+	}
+
+	return t.Lookup(files[0]), nil
+}
+
+// templateFiles expands template directories to the template files.
+func templateFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read book template: %v", err)
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		dirFiles, err := filepath.Glob(filepath.Join(path, "*"+bookTemplateExt))
+		if err != nil {
+			return nil, err
+		}
+
+		if len(dirFiles) == 0 {
+			return nil, fmt.Errorf("could not find %s files in %s", bookTemplateExt, path)
+		}
+
+		// the entry template goes first.
+		entry := filepath.Join(path, bookTemplateFile)
+		sort.SliceStable(dirFiles, func(i, j int) bool {
+			return dirFiles[i] == entry && dirFiles[j] != entry
+		})
+		files = append(files, dirFiles...)
+	}
+
+	return files, nil
+}
+
+// getBookSettings returns built-in book settings merged with the user and project defined ones.
 func getBookSettings() (map[types.BookType]bookSettings, error) {
-	var settings map[types.BookType]bookSettings
-	if err := json.Unmarshal(bookSettingsData, &settings); err != nil {
+	settings, err := parseBookSettings(bookSettingsData)
+	if err != nil {
 		return nil, err
 	}
 
+	for _, dir := range bookSettingsDirs() {
+		dirSettings, err := readBookSettingsDir(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		for bookType, s := range dirSettings {
+			settings[bookType] = s
+		}
+	}
+
 	return settings, nil
 }
 
@@ -1,6 +1,9 @@
 package template
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/MonkeyBuisness/celli/notebook/types"
@@ -61,3 +64,34 @@ MIT
 		require.Contains(t, string(data), expData)
 	})
 }
+
+func Test_NewBookTemplate_TemplatePaths(t *testing.T) {
+	baseDir, courseDir := t.TempDir(), t.TempDir()
+	for path, data := range map[string]string{
+		filepath.Join(baseDir, "book.tpl.md"): `# {{ block "title" . }}Untitled{{ end }}
+{{ block "body" . }}Nothing here yet.{{ end }}
+`,
+		filepath.Join(baseDir, "footer.tpl.md"):    `{{ define "footer" }}MIT{{ end }}`,
+		filepath.Join(baseDir, "notes.md"):         `{{ define "title" }}Ignored{{ end }}`,
+		filepath.Join(courseDir, "loops.tpl.md"):   `{{ define "title" }}Loops{{ end }}`,
+		filepath.Join(courseDir, "content.tpl.md"): `{{ define "body" }}{{ .Code | asJSON }}{{ template "footer" }}{{ end }}`,
+	} {
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+	}
+
+	t.Run("all ok", func(t *testing.T) {
+		data, err := NewBookTemplate(types.BookTypeJavaBook, WithTemplatePaths(
+			baseDir,
+			filepath.Join(courseDir, "loops.tpl.md"),
+			filepath.Join(courseDir, "content.tpl.md"),
+		))
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(string(data), "# Loops\n\n\t\"lang\": \"java\""), string(data))
+		require.True(t, strings.HasSuffix(string(data), "MIT\n"), string(data))
+	})
+	t.Run("empty dir", func(t *testing.T) {
+		emptyDir := t.TempDir()
+		_, err := NewBookTemplate(types.BookTypeJavaBook, WithTemplatePaths(emptyDir))
+		require.EqualError(t, err, "could not find .tpl.md files in "+emptyDir)
+	})
+}
@@ -10,3 +10,22 @@ type SerializableComment interface {
 	Key() string
 	Render(notebook *NotebookData, payload []byte) error
 }
+
+// URIResolver represents API to read the content of the URI.
+type URIResolver interface {
+	Resolve(uri string) ([]byte, error)
+}
+
+// CommentContext represents the context of the template the comment is rendered in.
+type CommentContext struct {
+	SourceName string
+	Resolver   URIResolver
+}
+
+// ContextualComment represents API of the serializable comment that requires the template context.
+//
+// Serializer calls RenderContext instead of Render for such comments.
+type ContextualComment interface {
+	SerializableComment
+	RenderContext(ctx *CommentContext, notebook *NotebookData, payload []byte) error
+}
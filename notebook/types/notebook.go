@@ -1,5 +1,7 @@
 package types
 
+import "encoding/json"
+
 // MarkdownLanguageID is an ID of the markup language.
 const MarkdownLanguageID = "markdown"
 
@@ -26,6 +28,19 @@ type NotebookCellData struct {
 	Content    string                 `json:"content"`
 	Kind       NotebookCellKind       `json:"kind"`
 	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	Outputs    []NotebookCellOutput   `json:"outputs,omitempty"`
+}
+
+// NotebookCellOutput represents the result of the code cell execution.
+type NotebookCellOutput struct {
+	Items    []NotebookCellOutputItem `json:"items"`
+	Metadata map[string]interface{}   `json:"metadata,omitempty"`
+}
+
+// NotebookCellOutputItem represents the output data of the specific MIME type.
+type NotebookCellOutputItem struct {
+	Mime string `json:"mime"`
+	Data string `json:"data"`
 }
 
 // NotebookCellKind represents notebook cell kind.
@@ -37,9 +52,126 @@ type NotebookCellKind int
 // BookType represents book type.
 type BookType string
 
-// SupportedBookTypes returns a slice of supported book type names.
+// SupportedBookTypes returns a slice of built-in book type names.
+//
+// User defined book types are loaded by the template package.
 func SupportedBookTypes() []string {
 	return []string{
 		string(BookTypeJavaBook),
 	}
 }
+
+// SourceMetadataKey is a reserved cell metadata key that keeps the template source form of the cell.
+const SourceMetadataKey = "celli"
+
+// Cell source style.
+const (
+	CellSourceStyleJSON  CellSourceStyle = "json"
+	CellSourceStyleYAML  CellSourceStyle = "yaml"
+	CellSourceStyleFence CellSourceStyle = "fence"
+)
+
+// CellSourceStyle represents the style of the template source the cell was created from.
+type CellSourceStyle string
+
+// CellSource represents the template source form of the cell.
+type CellSource struct {
+	Comment string          `json:"comment,omitempty"`
+	URI     string          `json:"uri,omitempty"`
+	Lines   string          `json:"lines,omitempty"`
+	Region  string          `json:"region,omitempty"`
+	SHA256  string          `json:"sha256,omitempty"`
+	Style   CellSourceStyle `json:"style"`
+	// Checksum is SHA-256 hash of the cell content loaded from the URI, it detects the edited cells.
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// SetSource records the template source form of the cell in the reserved metadata namespace.
+func (c *NotebookCellData) SetSource(src CellSource) {
+	if c.Metadata == nil {
+		c.Metadata = make(map[string]interface{})
+	}
+
+	// keep the value JSON-like, so it compares equal after the round trip.
+	source := map[string]interface{}{
+		"style": string(src.Style),
+	}
+	if src.Comment != "" {
+		source["comment"] = src.Comment
+	}
+	if src.URI != "" {
+		source["uri"] = src.URI
+	}
+	if src.Lines != "" {
+		source["lines"] = src.Lines
+	}
+	if src.Region != "" {
+		source["region"] = src.Region
+	}
+	if src.SHA256 != "" {
+		source["sha256"] = src.SHA256
+	}
+	if src.Checksum != "" {
+		source["checksum"] = src.Checksum
+	}
+
+	c.Metadata[SourceMetadataKey] = map[string]interface{}{
+		"source": source,
+	}
+}
+
+// Source returns the template source form of the cell if it is recorded in the metadata.
+func (c *NotebookCellData) Source() (CellSource, bool) {
+	var (
+		src       CellSource
+		namespace struct {
+			Source *CellSource `json:"source"`
+		}
+	)
+
+	value, ok := c.Metadata[SourceMetadataKey]
+	if !ok {
+		return src, false
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return src, false
+	}
+
+	if err := json.Unmarshal(data, &namespace); err != nil || namespace.Source == nil {
+		return src, false
+	}
+
+	return *namespace.Source, true
+}
+
+// CellIDMetadataKey is a cell metadata key that keeps the stable ID of the cell.
+const CellIDMetadataKey = "id"
+
+// ID returns the stable ID of the cell from the metadata or an empty string if it is not provided.
+func (c *NotebookCellData) ID() string {
+	id, _ := c.Metadata[CellIDMetadataKey].(string)
+	return id
+}
+
+// UserMetadata returns cell metadata without the reserved namespace.
+func (c *NotebookCellData) UserMetadata() map[string]interface{} {
+	if _, ok := c.Metadata[SourceMetadataKey]; !ok {
+		return c.Metadata
+	}
+
+	meta := make(map[string]interface{}, len(c.Metadata))
+	for key, value := range c.Metadata {
+		if key == SourceMetadataKey {
+			continue
+		}
+		meta[key] = value
+	}
+
+	if len(meta) == 0 {
+		return nil
+	}
+
+	return meta
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	// maxSymlinks is the maximum number of symbolic links followed to resolve the file path.
+	maxSymlinks = 255
+	// maxTempAttempts is the maximum number of the temporary file names tried.
+	maxTempAttempts = 10000
+)
+
+// WriteFileAtomic writes data to the file via temporary file in the same directory
+// that replaces the file, so the file is never left partially written.
+//
+// Symbolic links are followed, so the target file is replaced instead of the link,
+// and the mode of the existing file is kept. New files are created with perm (before umask).
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	path, err := resolveSymlinks(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+
+	keepMode := false
+	if info, err := os.Stat(path); err == nil {
+		perm, keepMode = info.Mode().Perm(), true
+	}
+
+	tmp, err := createTemp(filepath.Dir(path), "."+filepath.Base(path), perm)
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		Close(tmp)
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	// the umask is applied to the new temporary file, the mode of the existing file is restored as is.
+	if keepMode {
+		if err := os.Chmod(tmpPath, perm); err != nil {
+			_ = os.Remove(tmpPath)
+			return err
+		}
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	return nil
+}
+
+// createTemp creates new temporary file with the prefix in the directory,
+// so the kernel applies the umask to the permissions.
+func createTemp(dir, prefix string, perm os.FileMode) (*os.File, error) {
+	for i := 0; i < maxTempAttempts; i++ {
+		path := filepath.Join(dir, prefix+"."+strconv.FormatUint(uint64(rand.Uint32()), 10)+".tmp") // #nosec G404
+		file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+
+		return file, err
+	}
+
+	return nil, fmt.Errorf("could not create temporary file in %s", dir)
+}
+
+// resolveSymlinks returns the path of the file the symbolic links point to (even if it does not exist yet).
+func resolveSymlinks(path string) (string, error) {
+	for i := 0; i < maxSymlinks; i++ {
+		info, err := os.Lstat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return path, nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if info.Mode()&os.ModeSymlink == 0 {
+			return path, nil
+		}
+
+		target, err := os.Readlink(path)
+		if err != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		path = target
+	}
+
+	return "", fmt.Errorf("%s: too many levels of symbolic links", path)
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "book.md")
+	require.NoError(t, os.WriteFile(path, []byte("long previous content"), 0o600))
+
+	require.NoError(t, WriteFileAtomic(path, []byte("new"), 0o600))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "new", string(data))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
+func Test_WriteFileAtomic_ExistingFile(t *testing.T) {
+	t.Run("mode is kept", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "run.sh")
+		require.NoError(t, os.WriteFile(path, []byte("echo old"), 0o700))
+		require.NoError(t, os.Chmod(path, 0o750))
+
+		require.NoError(t, WriteFileAtomic(path, []byte("echo new"), 0o600))
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0o750), info.Mode().Perm())
+	})
+	t.Run("symlink is followed", func(t *testing.T) {
+		dir := t.TempDir()
+		target := filepath.Join(dir, "books", "book.md")
+		link := filepath.Join(dir, "book.md")
+		require.NoError(t, os.MkdirAll(filepath.Dir(target), 0o700))
+		require.NoError(t, os.WriteFile(target, []byte("old"), 0o600))
+		if err := os.Symlink(filepath.Join("books", "book.md"), link); err != nil {
+			t.Skipf("symlinks are not supported: %v", err)
+		}
+
+		require.NoError(t, WriteFileAtomic(link, []byte("new"), 0o600))
+
+		info, err := os.Lstat(link)
+		require.NoError(t, err)
+		require.NotZero(t, info.Mode()&os.ModeSymlink)
+
+		data, err := os.ReadFile(target)
+		require.NoError(t, err)
+		require.Equal(t, "new", string(data))
+	})
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+// +build !windows
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WriteFileAtomic_Umask(t *testing.T) {
+	defer syscall.Umask(syscall.Umask(0o022))
+
+	path := filepath.Join(t.TempDir(), "book.md")
+	require.NoError(t, WriteFileAtomic(path, []byte("new"), 0o666))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o644), info.Mode().Perm())
+}
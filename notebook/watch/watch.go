@@ -0,0 +1,201 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"sort"
+	"time"
+)
+
+// Default polling settings.
+const (
+	DefaultInterval = 250 * time.Millisecond
+	DefaultDebounce = 300 * time.Millisecond
+)
+
+// Watcher represents files watcher that polls modification time and size of the files.
+//
+// Polling is used instead of the file system notifications to behave the same way on every platform
+// and with the editors that replace files on save.
+type Watcher struct {
+	opts  Options
+	files map[string]fileState
+}
+
+// Options represents watcher options model.
+type Options struct {
+	interval time.Duration
+	debounce time.Duration
+}
+
+// Option represents watcher option.
+type Option func(*Options)
+
+// Snapshot represents the states of the files remembered at the time they were added.
+type Snapshot struct {
+	files map[string]fileState
+}
+
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// WithInterval sets the polling interval.
+func WithInterval(interval time.Duration) Option {
+	return func(o *Options) {
+		if interval > 0 {
+			o.interval = interval
+		}
+	}
+}
+
+// WithDebounce sets the time the files have to stay unchanged before the change is reported.
+func WithDebounce(debounce time.Duration) Option {
+	return func(o *Options) {
+		if debounce >= 0 {
+			o.debounce = debounce
+		}
+	}
+}
+
+// New returns new Watcher instance.
+func New(opt ...Option) *Watcher {
+	opts := Options{
+		interval: DefaultInterval,
+		debounce: DefaultDebounce,
+	}
+	for _, o := range opt {
+		o(&opts)
+	}
+
+	return &Watcher{
+		opts:  opts,
+		files: make(map[string]fileState),
+	}
+}
+
+// Watch replaces the set of watched files and remembers their current state.
+//
+// Files that do not exist are watched too: their creation is reported as a change.
+func (w *Watcher) Watch(paths ...string) {
+	snapshot := NewSnapshot()
+	for _, path := range paths {
+		snapshot.Add(path)
+	}
+	w.WatchSnapshot(snapshot)
+}
+
+// WatchSnapshot replaces the set of watched files with the files of the snapshot,
+// so the changes made after the snapshot was taken are reported by the next Wait.
+func (w *Watcher) WatchSnapshot(snapshot *Snapshot) {
+	files := make(map[string]fileState, len(snapshot.files))
+	for path, state := range snapshot.files {
+		files[path] = state
+	}
+	w.files = files
+}
+
+// Wait blocks until any of the watched files is changed and then stays unchanged for the debounce time,
+// so a burst of saves is reported once.
+//
+// Wait returns the changed files or the context error if the context is done.
+func (w *Watcher) Wait(ctx context.Context) ([]string, error) {
+	ticker := time.NewTicker(w.opts.interval)
+	defer ticker.Stop()
+
+	var (
+		changed   []string
+		changedAt time.Time
+	)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case now := <-ticker.C:
+			if paths := w.poll(); len(paths) != 0 {
+				changed = appendUnique(changed, paths...)
+				changedAt = now
+				continue
+			}
+
+			if len(changed) != 0 && now.Sub(changedAt) >= w.opts.debounce {
+				return changed, nil
+			}
+		}
+	}
+}
+
+// poll updates the state of the watched files and returns the changed ones.
+func (w *Watcher) poll() []string {
+	var changed []string
+	for path, prev := range w.files {
+		if cur := stat(path); !cur.equal(prev) {
+			w.files[path] = cur
+			changed = append(changed, path)
+		}
+	}
+
+	return changed
+}
+
+// NewSnapshot returns new empty Snapshot instance.
+func NewSnapshot() *Snapshot {
+	return &Snapshot{
+		files: make(map[string]fileState),
+	}
+}
+
+// Add remembers the current state of the file, the state of the file added before is kept.
+func (s *Snapshot) Add(path string) {
+	if _, ok := s.files[path]; !ok {
+		s.files[path] = stat(path)
+	}
+}
+
+// Paths returns the sorted paths of the files.
+func (s *Snapshot) Paths() []string {
+	paths := make([]string, 0, len(s.files))
+	for path := range s.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	return paths
+}
+
+func stat(path string) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+
+	return fileState{
+		exists:  true,
+		size:    info.Size(),
+		modTime: info.ModTime(),
+	}
+}
+
+func (s fileState) equal(other fileState) bool {
+	return s.exists == other.exists && s.size == other.size && s.modTime.Equal(other.modTime)
+}
+
+func appendUnique(paths []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, path := range paths {
+			if path == value {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			paths = append(paths, value)
+		}
+	}
+
+	return paths
+}
@@ -0,0 +1,64 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher_Wait(t *testing.T) {
+	dir := t.TempDir()
+	watched := filepath.Join(dir, "book.md")
+	created := filepath.Join(dir, "chapter.md")
+	require.NoError(t, os.WriteFile(watched, []byte("# Book"), 0o600))
+
+	w := New(WithInterval(10*time.Millisecond), WithDebounce(50*time.Millisecond))
+
+	t.Run("burst of changes is reported once", func(t *testing.T) {
+		w.Watch(watched, created)
+
+		go func() {
+			for i := 0; i < 3; i++ {
+				time.Sleep(20 * time.Millisecond)
+				_ = os.WriteFile(watched, []byte("# Book"+string(rune('a'+i))), 0o600)
+			}
+			_ = os.WriteFile(created, []byte("# Chapter"), 0o600)
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		changed, err := w.Wait(ctx)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{watched, created}, changed)
+	})
+
+	t.Run("changes after the snapshot are reported", func(t *testing.T) {
+		snapshot := NewSnapshot()
+		snapshot.Add(watched)
+		require.NoError(t, os.WriteFile(watched, []byte("# Book changed during the build"), 0o600))
+		snapshot.Add(watched)
+		w.WatchSnapshot(snapshot)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		changed, err := w.Wait(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{watched}, changed)
+	})
+
+	t.Run("context is done", func(t *testing.T) {
+		w.Watch(watched)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := w.Wait(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}